@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadAllowList reads a YAML file mapping package names to the version range
+// that may remain in userlib, e.g.
+//
+//	org.apache.velocity: ">=1.7 <2"
+//	org.junit: "4.11"
+func loadAllowList(path string) map[string]versionRange {
+	allowList := loadVersionRanges(path, "allow-list")
+	log.Infof("Loaded %d allow-list entries from %v", len(allowList), path)
+	return allowList
+}
+
+// loadVersionRanges reads a YAML file mapping package names to version ranges.
+func loadVersionRanges(path string, what string) map[string]versionRange {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read %v: %v", what, err)
+	}
+	entries := make(map[string]string)
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		fatalf("Unable to parse %v %v: %v", what, path, err)
+	}
+
+	ranges := make(map[string]versionRange)
+	for packageName, text := range entries {
+		vr, err := parseVersionRange(text)
+		if err != nil {
+			fatalf("Invalid %v entry for %v: %v", what, packageName, err)
+		}
+		ranges[normalizeKey(packageName)] = vr
+	}
+	return ranges
+}
+
+// newestInRange returns the newest JAR of the package with a version in vr.
+func newestInRange(jars []JarProperties, packageName string, vr versionRange) (JarProperties, bool) {
+	found := false
+	var best JarProperties
+	for _, jar := range jars {
+		if jar.key() != packageName || !vr.contains(jar.version) {
+			continue
+		}
+		if !found || compareVersions(best.version, jar.version) < 0 {
+			best = jar
+			found = true
+		}
+	}
+	return best, found
+}
+
+// applyAllowList drops every package that is not approved. If the kept version
+// of an approved package is outside of its range the newest approved version is
+// kept instead. JARs left out of deduplication, like shaded, resource-only and
+// unidentified ones, are keyed by their path and not checked.
+func applyAllowList(jars []JarProperties, keepJars map[string]JarProperties, allowList map[string]versionRange) {
+	log.Info("Checking JARs against allow-list")
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		if keepJars[packageName].unique != "" {
+			log.Debugf("Not checking %v against the allow-list, it is not deduplicated", keepJars[packageName].fileName)
+			continue
+		}
+		vr, ok := allowList[packageName]
+		if !ok {
+			log.Warningf("Not on allow-list: %v (%v)", packageName, keepJars[packageName].fileName)
+			for _, jar := range jars {
+				if jar.key() == packageName {
+					addFinding(Finding{Type: "not-allowed", Severity: severityWarning, Package: packageName,
+						Message: fmt.Sprintf("%v is not on the allow-list", jar.fileName), Files: []string{jar.filePath}, SuggestedFix: removeFileFix(jar.filePath)})
+				}
+			}
+			delete(keepJars, packageName)
+			continue
+		}
+		if vr.contains(keepJars[packageName].version) {
+			continue
+		}
+
+		if best, found := newestInRange(jars, packageName, vr); found {
+			log.Warningf("Version %v of %v is not allowed by %v, keeping %v instead", keepJars[packageName].version, packageName, vr, best.fileName)
+			addFinding(Finding{Type: "not-allowed", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("Version %v of %v is not allowed by %v", keepJars[packageName].version, packageName, vr),
+				Files:        []string{keepJars[packageName].filePath},
+				SuggestedFix: removeFileFix(keepJars[packageName].filePath)})
+			keepJars[packageName] = best
+		} else {
+			log.Warningf("No allowed version of %v found (allowed: %v)", packageName, vr)
+			addFinding(Finding{Type: "not-allowed", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("No version of %v allowed by %v is present", packageName, vr),
+				Files:        []string{keepJars[packageName].filePath},
+				SuggestedFix: &SuggestedFix{Action: "update-module", Target: packageName, Detail: "install a version matching " + vr.String()}})
+			delete(keepJars, packageName)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestAllowListSkipsJarsLeftOutOfDeduplication(t *testing.T) {
+	defer resetFindings()
+	shaded := JarProperties{filePath: "userlib/uber-app-1.0-all.jar", fileName: "uber-app-1.0-all.jar", shaded: []string{"a:b", "c:d"},
+		Coordinates: Coordinates{group: "com.example", artifact: "uber-app", version: "1.0", unique: "userlib/uber-app-1.0-all.jar"}}
+	resources := JarProperties{filePath: "userlib/icons.jar", fileName: "icons.jar", resourceOnly: true,
+		Coordinates: Coordinates{artifact: "icons", unique: "userlib/icons.jar"}}
+	unidentified := JarProperties{filePath: "userlib/vendor.jar", fileName: "vendor.jar",
+		Coordinates: Coordinates{artifact: "userlib/vendor.jar", unique: "userlib/vendor.jar"}}
+	other := JarProperties{filePath: "userlib/junit-4.11.jar", fileName: "junit-4.11.jar",
+		Coordinates: Coordinates{group: "junit", artifact: "junit", version: "4.11"}}
+	jars := []JarProperties{shaded, resources, unidentified, other}
+	keepJars := make(map[string]JarProperties)
+	for _, jar := range jars {
+		keepJars[jar.key()] = jar
+	}
+
+	applyAllowList(jars, keepJars, map[string]versionRange{})
+	for _, jar := range []JarProperties{shaded, resources, unidentified} {
+		if _, ok := keepJars[jar.key()]; !ok {
+			t.Errorf("%v was dropped by the allow-list", jar.fileName)
+		}
+	}
+	if _, ok := keepJars[other.key()]; ok {
+		t.Errorf("%v is kept although it is not on the allow-list", other.fileName)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// archiveExtensions are the extensions of the files in userlib that are
+// analyzed like JARs, set with --extensions.
+var archiveExtensions = []string{".jar"}
+
+func setArchiveExtensions(values []string) {
+	if len(values) == 0 {
+		fatal("--extensions needs at least one extension")
+	}
+	archiveExtensions = []string{}
+	for _, value := range values {
+		extension := "." + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), ".")
+		if extension == "." || strings.ContainsAny(extension, `/\`) {
+			fatalf("Invalid --extensions value %q, expected an extension like jar or zip", value)
+		}
+		archiveExtensions = append(archiveExtensions, extension)
+	}
+}
+
+// archiveExtension returns the extension of a library like foo-1.0.aar, or
+// an empty string for other files.
+func archiveExtension(fileName string) string {
+	extension := strings.ToLower(filepath.Ext(fileName))
+	if contains(archiveExtensions, extension) {
+		return filepath.Ext(fileName)
+	}
+	return ""
+}
+
+func isArchive(fileName string) bool {
+	return archiveExtension(fileName) != ""
+}
+
+// trimArchiveExtension returns the file name of a library without its
+// extension, e.g. foo-1.0 for foo-1.0.zip.
+func trimArchiveExtension(fileName string) string {
+	return strings.TrimSuffix(fileName, archiveExtension(fileName))
+}
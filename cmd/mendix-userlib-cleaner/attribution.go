@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ownershipModule = "module-owned"
+	ownershipShared = "shared"
+)
+
+// attributeModules records which Marketplace modules require each JAR. Studio
+// Pro writes a marker per module next to the JAR, e.g.
+// commons-io-2.6.jar.CommunityCommons.RequiredLib. A marker containing a
+// version range, like ">=2.6 <2.9", limits the versions the module accepts.
+func attributeModules(filePaths []string, jars []JarProperties) {
+	for i := range jars {
+		jars[i].requirements = make(map[string]versionRange)
+		prefix := jars[i].fileName + "."
+		for _, filePath := range filePaths {
+			fileName := filepath.Base(filePath)
+			if filepath.Dir(filePath) != filepath.Dir(jars[i].filePath) || !strings.HasPrefix(fileName, prefix) || !strings.HasSuffix(fileName, ".RequiredLib") {
+				continue
+			}
+			module := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), ".RequiredLib")
+			if module == "" || contains(jars[i].modules, module) {
+				continue
+			}
+			jars[i].modules = append(jars[i].modules, module)
+			if vr, ok := moduleRequirement(filePath); ok {
+				jars[i].requirements[module] = vr
+			}
+		}
+		sort.Strings(jars[i].modules)
+		if len(jars[i].modules) > 0 {
+			log.Debugf("%v is required by %v", jars[i].fileName, strings.Join(jars[i].modules, ", "))
+		}
+	}
+}
+
+// requiredLibConstraint matches a version constraint in a marker, like
+// ">=2.6" or "2.6.1".
+var requiredLibConstraint = regexp.MustCompile(`^(>=|<=|!=|>|<|=)?[0-9][0-9A-Za-z.+_-]*$`)
+
+// isRequiredLibRange tells whether the content of a marker is made of version
+// constraints only, or is "*".
+func isRequiredLibRange(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 1 && fields[0] == "*" {
+		return true
+	}
+	for _, field := range fields {
+		if !requiredLibConstraint.MatchString(field) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// moduleRequirement returns the version range in a marker. Markers are usually
+// empty, so a module only requires versions when its marker holds a range.
+func moduleRequirement(markerPath string) (versionRange, bool) {
+	b, err := ioutil.ReadFile(markerPath)
+	if err != nil {
+		degradeCheck("module requirements", fmt.Sprintf("unable to read %v: %v", filepath.Base(markerPath), err))
+		return versionRange{}, false
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		return versionRange{}, false
+	}
+	if !isRequiredLibRange(string(b)) {
+		log.Warningf("Ignoring %v, it does not contain a version range", markerPath)
+		return versionRange{}, false
+	}
+	vr, err := parseVersionRange(string(b))
+	if err != nil {
+		log.Warningf("Ignoring invalid version range in %v: %v", markerPath, err)
+		degradeCheck("module requirements", fmt.Sprintf("invalid version range in %v", filepath.Base(markerPath)))
+		return vr, false
+	}
+	return vr, true
+}
+
+// applyModuleRequirements makes sure the kept version of each package
+// satisfies the requirements of every module. If the newest version does not,
+// the newest one that does is kept. If no version does, a conflict is reported
+// and the newest version is kept.
+func applyModuleRequirements(jars []JarProperties, keepJars map[string]JarProperties) {
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		requirements := make(map[string]versionRange)
+		for _, jar := range jars {
+			if jar.key() != packageName {
+				continue
+			}
+			for module, vr := range jar.requirements {
+				requirements[module] = vr
+			}
+		}
+		if len(requirements) == 0 || unsatisfiedRequirement(keepJars[packageName], requirements) == "" {
+			continue
+		}
+
+		found := false
+		var best JarProperties
+		for _, jar := range jars {
+			if jar.key() != packageName || unsatisfiedRequirement(jar, requirements) != "" {
+				continue
+			}
+			if !found || compareVersions(best.version, jar.version) < 0 {
+				best = jar
+				found = true
+			}
+		}
+		keepJar := keepJars[packageName]
+		if found {
+			log.Warningf("%v does not satisfy the requirement of %v, keeping %v instead", keepJar.fileName, unsatisfiedRequirement(keepJar, requirements), best.fileName)
+			addFinding(Finding{Type: "module-requirement", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("%v does not satisfy %v, kept %v instead", keepJar.fileName, unsatisfiedRequirement(keepJar, requirements), best.fileName),
+				Files:        []string{keepJar.filePath, best.filePath},
+				SuggestedFix: removeFileFix(keepJar.filePath)})
+			keepJars[packageName] = best
+			continue
+		}
+
+		modules := []string{}
+		for module, vr := range requirements {
+			modules = append(modules, fmt.Sprintf("%v (%v)", module, vr))
+		}
+		sort.Strings(modules)
+		log.Warningf("No version of %v satisfies all modules: %v", packageName, strings.Join(modules, ", "))
+		addFinding(Finding{Type: "module-requirement-conflict", Severity: severityCritical, Package: packageName,
+			Message:      fmt.Sprintf("No version of %v satisfies all modules: %v", packageName, strings.Join(modules, ", ")),
+			Files:        []string{keepJar.filePath},
+			SuggestedFix: &SuggestedFix{Action: "update-module", Target: unsatisfiedRequirement(keepJar, requirements), Detail: "update the module to a release compatible with " + keepJar.fileName}})
+	}
+}
+
+// unsatisfiedRequirement returns the first module whose requirement the JAR
+// does not satisfy, or an empty string.
+func unsatisfiedRequirement(jar JarProperties, requirements map[string]versionRange) string {
+	modules := []string{}
+	for module := range requirements {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		if !requirements[module].contains(jar.version) {
+			return module
+		}
+	}
+	return ""
+}
+
+// restrictToModules returns the JARs required by one of the modules and drops
+// the findings not concerning them, so every output only covers those modules.
+func restrictToModules(jars []JarProperties, modules []string) []JarProperties {
+	if len(modules) == 0 {
+		return jars
+	}
+	restricted := []JarProperties{}
+	filePaths := []string{}
+	keys := []string{}
+	for _, jar := range jars {
+		for _, module := range modules {
+			if contains(jar.modules, module) {
+				restricted = append(restricted, jar)
+				filePaths = append(filePaths, jar.filePath)
+				keys = append(keys, jar.key())
+				break
+			}
+		}
+	}
+	retainFindings(func(finding Finding) bool {
+		for _, filePath := range finding.Files {
+			if contains(filePaths, filePath) {
+				return true
+			}
+		}
+		return len(finding.Files) == 0 && contains(keys, finding.Package)
+	})
+	log.Infof("Restricted to %d JAR(s) required by %v", len(restricted), strings.Join(modules, ", "))
+	return restricted
+}
+
+// ownership tells whether a JAR belongs to a single module, which is fixed by
+// updating that module, or is shared by several or added by hand, which has
+// to be consolidated manually.
+func ownership(jar JarProperties) string {
+	if len(jar.modules) == 1 {
+		return ownershipModule
+	}
+	return ownershipShared
+}
+
+// groupKeys returns the groups a JAR is listed in for --group-by.
+func groupKeys(jar JarProperties, groupBy string) []string {
+	switch groupBy {
+	case "vendor":
+		if jar.vendor == "" {
+			return []string{"unknown"}
+		}
+		return []string{jar.vendor}
+	case "package":
+		return []string{jar.key()}
+	default:
+		if len(jar.modules) == 0 {
+			return []string{"none"}
+		}
+		return jar.modules
+	}
+}
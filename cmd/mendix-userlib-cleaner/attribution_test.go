@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleRequirement(t *testing.T) {
+	tests := []struct {
+		content string
+		ok      bool
+		version string
+	}{
+		{"", false, ""},
+		{"\n", false, ""},
+		{">=2.6 <2.9", true, "2.7"},
+		{">=2.6 <2.9\n", true, "2.6"},
+		{"2.6.1", true, "2.6.1"},
+		{"*", true, "1.0"},
+		{"Required by CommunityCommons", false, ""},
+		{"latest", false, ""},
+		{">=2.6<2.9", false, ""},
+		{">=", false, ""},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		markerPath := filepath.Join(dir, "commons-io-2.6.jar.CommunityCommons.RequiredLib")
+		if err := ioutil.WriteFile(markerPath, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		vr, ok := moduleRequirement(markerPath)
+		if ok != test.ok {
+			t.Errorf("moduleRequirement(%q) = %v, want %v", test.content, ok, test.ok)
+			continue
+		}
+		if ok && !vr.contains(test.version) {
+			t.Errorf("range %v of %q does not contain %v", vr, test.content, test.version)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// azureAPIVersion is the version of the Blob service REST API requested.
+const azureAPIVersion = "2020-10-02"
+
+// azureStore reads a container of Azure Blob Storage with the SAS token in
+// AZURE_STORAGE_SAS_TOKEN, or anonymously for a public container. Emulators
+// like Azurite are reached at AZURE_STORAGE_ENDPOINT.
+type azureStore struct {
+	containerURL string
+	sasToken     string
+	client       http.Client
+}
+
+func newAzureStore(account string, container string) *azureStore {
+	endpoint := os.Getenv("AZURE_STORAGE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%v.blob.core.windows.net", account)
+	}
+	return &azureStore{
+		containerURL: strings.TrimSuffix(endpoint, "/") + "/" + container,
+		sasToken:     strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+		client:       http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (a *azureStore) list(prefix string, recursive bool) ([]remoteObject, error) {
+	objects := []remoteObject{}
+	marker := ""
+	for {
+		query := map[string]string{"restype": "container", "comp": "list", "prefix": prefix}
+		if !recursive {
+			query["delimiter"] = "/"
+		}
+		if marker != "" {
+			query["marker"] = marker
+		}
+		b, err := a.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := struct {
+			Blobs []struct {
+				Name string
+				Size int64 `xml:"Properties>Content-Length"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string
+		}{}
+		if err := xml.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("invalid listing of %v: %v", a.containerURL, err)
+		}
+		for _, blob := range result.Blobs {
+			objects = append(objects, remoteObject{key: blob.Name, size: blob.Size})
+		}
+		if result.NextMarker == "" {
+			return objects, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+func (a *azureStore) read(key string, offset int64, length int64) ([]byte, error) {
+	return a.do("GET", key, nil, map[string]string{"x-ms-range": rangeHeader(offset, length)})
+}
+
+func (a *azureStore) open(key string) (io.ReadCloser, error) {
+	return a.send("GET", key, nil, nil)
+}
+
+func (a *azureStore) remove(key string) error {
+	_, err := a.do("DELETE", key, nil, nil)
+	return err
+}
+
+func (a *azureStore) do(method string, key string, query map[string]string, headers map[string]string) ([]byte, error) {
+	body, err := a.send(method, key, query, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// send sends a request and returns the body of a successful response.
+func (a *azureStore) send(method string, key string, query map[string]string, headers map[string]string) (io.ReadCloser, error) {
+	url := a.containerURL
+	if key != "" {
+		url += "/" + s3Escape(key, true)
+	}
+	rawQuery := canonicalQuery(query)
+	if a.sasToken != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += a.sasToken
+	}
+	if rawQuery != "" {
+		url += "?" + rawQuery
+	}
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("x-ms-version", azureAPIVersion)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := a.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode/100 != 2 {
+		response.Body.Close()
+		return nil, fmt.Errorf("%v answered %v", request.URL.Host, response.Status)
+	}
+	return response.Body, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+// classFileMagic starts every class file, followed by its minor and major
+// version.
+const classFileMagic = 0xCAFEBABE
+
+// sampledClasses is the number of classes per JAR whose version is read.
+// Libraries are compiled for a single target, a sample finds the odd class.
+const sampledClasses = 16
+
+// firstJavaRelease is the class file major version of Java 5, from which
+// the release is the major version minus 44.
+const firstJavaRelease = 49
+
+// classMajorVersion reads the major version from the header of a class file.
+func classMajorVersion(f *zip.File) (int, error) {
+	r, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+	if binary.BigEndian.Uint32(header) != classFileMagic {
+		return 0, fmt.Errorf("%v is not a class file", f.Name)
+	}
+	return int(binary.BigEndian.Uint16(header[6:])), nil
+}
+
+// requiredClassVersion returns the highest class file major version of a
+// sample of the classes of a JAR, ignoring the classes of multi-release JARs
+// for newer Java versions under META-INF/versions, or 0 without classes.
+func requiredClassVersion(archive *zip.Reader) int {
+	required, sampled := 0, 0
+	for _, f := range archive.File {
+		if sampled == sampledClasses {
+			break
+		}
+		if classNameFromEntry(f.Name) == "" {
+			continue
+		}
+		sampled++
+		if major, err := classMajorVersion(f); err == nil && major > required {
+			required = major
+		}
+	}
+	return required
+}
+
+// javaRelease names the Java release a class file major version needs, e.g.
+// 11 for 55 and 1.4 for 48.
+func javaRelease(major int) string {
+	if major == 0 {
+		return ""
+	}
+	if major < firstJavaRelease {
+		return fmt.Sprintf("1.%d", major-44)
+	}
+	return strconv.Itoa(major - 44)
+}
+
+func checkRuntimeJavaVersion(value int) {
+	if value != 0 && value < 8 {
+		fatalf("Unsupported --java-version: %v, expected 8 or newer", value)
+	}
+}
+
+// checkJavaVersion reports kept JARs compiled for a newer Java than the one
+// of --java-version, which fail with UnsupportedClassVersionError as soon as
+// one of their classes is loaded.
+func checkJavaVersion(jars []JarProperties, keepJars map[string]JarProperties) {
+	runtime := viper.GetInt("java-version")
+	if runtime == 0 {
+		return
+	}
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+	for _, packageName := range packageNames {
+		jar := keepJars[packageName]
+		if jar.classVersion <= runtime+44 {
+			continue
+		}
+		log.Errorf("%v requires Java %v, newer than Java %d", jar.fileName, javaRelease(jar.classVersion), runtime)
+		addFinding(Finding{Type: "java-version", Severity: severityCritical, Package: packageName,
+			Message: fmt.Sprintf("%v is compiled for Java %v and cannot be loaded by Java %d", jar.fileName, javaRelease(jar.classVersion), runtime),
+			Files:   []string{jar.filePath},
+			SuggestedFix: &SuggestedFix{Action: "replace-file", Target: jar.filePath,
+				Detail: fmt.Sprintf("use a version of %v compiled for Java %d or older", packageName, runtime)}})
+	}
+}
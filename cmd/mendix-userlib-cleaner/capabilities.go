@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// reportSchemaVersion is raised when fields of the JSON report change
+// incompatibly. Added fields do not change it.
+const reportSchemaVersion = 1
+
+// Capabilities describes what this build supports, so orchestration can
+// adapt to the cleaner version deployed on each build agent.
+type Capabilities struct {
+	Version      string            `json:"version"`
+	Schemas      map[string]string `json:"schemas"`
+	Commands     []string          `json:"commands"`
+	Methods      []string          `json:"methods"`
+	Exporters    []string          `json:"exporters"`
+	MergeFormats []string          `json:"mergeFormats"`
+	Modes        []string          `json:"modes"`
+	Identities   []string          `json:"identities"`
+	FailOn       []string          `json:"failOn"`
+	Checks       []CapabilityCheck `json:"checks"`
+}
+
+type CapabilityCheck struct {
+	Rule string `json:"rule"`
+	Type string `json:"type"`
+}
+
+var rpcMethods = []string{"capabilities", "scan", "inspect", "plan", "apply", "exit"}
+
+func capabilities() Capabilities {
+	c := Capabilities{
+		Version: version,
+		Schemas: map[string]string{
+			"report": fmt.Sprint(reportSchemaVersion),
+			"sarif":  "2.1.0",
+			"rpc":    "2.0",
+		},
+		Commands:     []string{},
+		Methods:      rpcMethods,
+		Exporters:    []string{},
+		MergeFormats: []string{"json", "html", "csv"},
+		Modes:        []string{"auto", "strict", "m2ee-log"},
+		Identities:   []string{identityArtifact, identityGroupArtifact, identityBundle},
+		FailOn:       failOnLevels,
+		Checks:       []CapabilityCheck{},
+	}
+	for _, command := range commands {
+		c.Commands = append(c.Commands, command.name)
+	}
+	for name := range exporterFactories {
+		c.Exporters = append(c.Exporters, name)
+	}
+	sort.Strings(c.Exporters)
+	for _, rule := range rules {
+		c.Checks = append(c.Checks, CapabilityCheck{rule.ID, rule.Type})
+	}
+	return c
+}
+
+// serveHTTP answers GET /capabilities. It is read-only: the JSON-RPC
+// methods, which can remove files, are only served on --stdio.
+func serveHTTP(address string) {
+	http.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, capabilities())
+	})
+
+	log.Infof("Serving /capabilities at http://%v", address)
+	if err := http.ListenAndServe(address, nil); err != nil {
+		fatal(err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Warningf("Unable to write response: %v", err)
+	}
+}
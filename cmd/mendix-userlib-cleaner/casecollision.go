@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checkCaseCollisions reports files whose paths differ only in case, like
+// Foo.jar and foo.jar. They cannot coexist on the case-insensitive file
+// systems of Windows and macOS, so a checkout keeps only one of them.
+func checkCaseCollisions(targetDir string, filePaths []string, keepJars map[string]JarProperties) {
+	kept := make(map[string]bool)
+	for _, jar := range keepJars {
+		kept[jar.filePath] = true
+	}
+	collisions := make(map[string][]string)
+	for _, filePath := range filePaths {
+		folded := strings.ToLower(relativePath(targetDir, filePath))
+		collisions[folded] = append(collisions[folded], filePath)
+	}
+
+	folded := []string{}
+	for name, colliding := range collisions {
+		if len(colliding) > 1 {
+			folded = append(folded, name)
+		}
+	}
+	sort.Strings(folded)
+	for _, name := range folded {
+		colliding := collisions[name]
+		sort.Strings(colliding)
+		names := []string{}
+		target := ""
+		for _, filePath := range colliding {
+			names = append(names, relativePath(targetDir, filePath))
+			if target == "" && !kept[filePath] {
+				target = filePath
+			}
+		}
+		if target == "" {
+			target = colliding[len(colliding)-1]
+		}
+		log.Warningf("File names differ only in case: %v", strings.Join(names, ", "))
+		addFinding(Finding{Type: "case-collision", Severity: severityWarning, Package: name,
+			Message: fmt.Sprintf("%v differ only in case, a checkout on Windows or macOS keeps only one of them", strings.Join(names, " and ")),
+			Files:   colliding,
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: target,
+				Detail: "rename or remove all but one of " + strings.Join(names, ", ")}})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// changelogName names a package in the changelog by its artifact, or the
+// file name when the JAR is unidentified.
+func changelogName(jar JarProperties) string {
+	if isUnidentified(jar) || jar.artifact == "" {
+		return jar.fileName
+	}
+	return jar.artifact
+}
+
+// renderChangelog describes the library changes of a clean in Markdown for
+// the release notes of the app, e.g. "httpclient 4.5.10 → 4.5.14".
+func renderChangelog(clean bool, jars []JarProperties, keepJars map[string]JarProperties) string {
+	held := make(map[string]bool)
+	for _, finding := range allFindings() {
+		if finding.Type == "held" {
+			for _, filePath := range finding.Files {
+				held[filePath] = true
+			}
+		}
+	}
+
+	removed := make(map[string][]JarProperties)
+	for _, jar := range jars {
+		if _, guarded := guardReason(jar.filePath); keepJars[jar.key()].filePath != jar.filePath && !held[jar.filePath] && !guarded {
+			removed[jar.key()] = append(removed[jar.key()], jar)
+		}
+	}
+	packageNames := []string{}
+	for packageName := range removed {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	updated, duplicates, dropped := []string{}, []string{}, []string{}
+	for _, packageName := range packageNames {
+		kept, ok := keepJars[packageName]
+		note := ""
+		for _, jar := range removed[packageName] {
+			if isCriticalPackage(jar.fullKey()) {
+				note = " (critical package, confirmed per file)"
+			}
+		}
+		if !ok {
+			for _, jar := range removed[packageName] {
+				dropped = append(dropped, strings.TrimSpace(fmt.Sprintf("%v %v", changelogName(jar), jar.version))+note)
+			}
+			continue
+		}
+		older, newer := []string{}, []string{}
+		for _, jar := range removed[packageName] {
+			switch order := compareVersions(jar.version, kept.version); {
+			case jar.version == "":
+				duplicates = append(duplicates, fmt.Sprintf("%v without version (%v)%v", changelogName(kept), jar.fileName, note))
+			case order == 0:
+				duplicates = append(duplicates, fmt.Sprintf("%v %v (%v)%v", changelogName(kept), kept.version, jar.fileName, note))
+			case order < 0 && !contains(older, jar.version):
+				older = append(older, jar.version)
+			case order > 0 && !contains(newer, jar.version):
+				newer = append(newer, jar.version)
+			}
+		}
+		sort.Slice(older, func(i, j int) bool { return compareVersions(older[i], older[j]) < 0 })
+		sort.Slice(newer, func(i, j int) bool { return compareVersions(newer[i], newer[j]) < 0 })
+		if len(older) > 0 {
+			updated = append(updated, fmt.Sprintf("%v %v → %v%v", changelogName(kept), strings.Join(older, ", "), kept.version, note))
+		}
+		if len(newer) > 0 {
+			updated = append(updated, fmt.Sprintf("%v %v → %v (downgrade)%v", changelogName(kept), strings.Join(newer, ", "), kept.version, note))
+		}
+	}
+
+	var b strings.Builder
+	if clean {
+		b.WriteString("## Library changes\n")
+	} else {
+		b.WriteString("## Planned library changes\n")
+	}
+	if len(updated)+len(duplicates)+len(dropped) == 0 {
+		b.WriteString("\nNo changes to the libraries in userlib.\n")
+		return b.String()
+	}
+	writeChangelogSection(&b, "Updated", updated)
+	writeChangelogSection(&b, "Removed duplicates", duplicates)
+	writeChangelogSection(&b, "Removed", dropped)
+	return b.String()
+}
+
+func writeChangelogSection(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %v\n\n", title)
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %v\n", line)
+	}
+}
+
+// writeChangelog writes the changelog of the run to path.
+func writeChangelog(path string, clean bool, jars []JarProperties, keepJars map[string]JarProperties) {
+	if err := writeFileAtomic(path, []byte(renderChangelog(clean, jars, keepJars)), 0644); err != nil {
+		fatalf("Unable to write changelog %v: %v", path, err)
+	}
+	log.Infof("Wrote changelog to %v", path)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// fileSHA256 returns the hex encoded SHA-256 checksum of a file.
+func fileSHA256(filePath string) (string, error) {
+	return fileChecksum(filePath, "sha256", sha256.New)
+}
+
+// fileChecksum hashes the content of a file. The stand-in of a remote JAR
+// is hashed as the object it stands for, which is streamed from the store
+// once per algorithm.
+func fileChecksum(filePath string, algorithm string, newHash func() hash.Hash) (string, error) {
+	if isRemoteStandIn(filePath) {
+		return remoteChecksum(filePath, algorithm, newHash)
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readChecksum(f, newHash)
+}
+
+func readChecksum(r io.Reader, newHash func() hash.Hash) (string, error) {
+	hash := newHash()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// companionSuffix matches the classifiers of JARs that are attached to a JAR
+// of the same artifact and version, like its sources, rather than being an
+// alternative to it.
+var companionSuffix = regexp.MustCompile(`-(test-sources|test-javadoc|sources|javadoc|tests|natives-[a-z0-9_-]+)\.jar$`)
+
+// companionClassifier returns the classifier of a companion JAR like
+// foo-1.2-sources.jar or foo-1.2-natives-linux.jar, or an empty string.
+func companionClassifier(fileName string) string {
+	match := companionSuffix.FindStringSubmatch(strings.ToLower(fileName))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// companionOf returns the file name of the JAR a companion belongs to, e.g.
+// foo-1.2.jar for foo-1.2-sources.jar.
+func companionOf(jar JarProperties) string {
+	return jar.fileName[:len(jar.fileName)-len("-"+jar.classifier+".jar")] + ".jar"
+}
+
+// parseCompanionFileName identifies a companion without metadata, which
+// usually has no classes either, by its file name: bar-2.0-sources.jar is
+// version 2.0 of bar.
+func parseCompanionFileName(filePath string) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	classifier := companionClassifier(jarProp.fileName)
+	if classifier == "" {
+		return jarProp
+	}
+	main := jarProp.fileName[:len(jarProp.fileName)-len("-"+classifier+".jar")] + ".jar"
+	version := versionFromFileName(main)
+	artifact := strings.TrimSuffix(strings.TrimSuffix(main, ".jar"), "-"+version)
+	if version != "" && artifact != "" {
+		jarProp.artifact, jarProp.version = artifact, version
+	}
+	return jarProp
+}
+
+func isCompanionClassifier(classifier string) bool {
+	return classifier != "" && companionClassifier("-"+classifier+".jar") == classifier
+}
+
+func isCompanion(jar JarProperties) bool {
+	return isCompanionClassifier(jar.classifier) && strings.HasSuffix(jar.fileName, "-"+jar.classifier+".jar")
+}
+
+// splitCompanions separates companion JARs, which follow the decision made
+// for the JAR they belong to.
+func splitCompanions(jars []JarProperties) ([]JarProperties, []JarProperties) {
+	mains, companions := []JarProperties{}, []JarProperties{}
+	for _, jar := range jars {
+		if isCompanion(jar) {
+			companions = append(companions, jar)
+		} else {
+			mains = append(mains, jar)
+		}
+	}
+	return mains, companions
+}
+
+// applyCompanions keeps companions like sources, javadoc, tests and natives
+// JARs paired with the kept JAR they belong to and removes those of removed
+// versions. Companions whose JAR is absent are deduplicated among themselves.
+func applyCompanions(mains []JarProperties, companions []JarProperties, keepJars map[string]JarProperties) {
+	byFileName := make(map[string]JarProperties)
+	for _, jar := range mains {
+		byFileName[jar.fileName] = jar
+	}
+
+	groups := make(map[string][]JarProperties)
+	packageNames := []string{}
+	for _, jar := range companions {
+		if _, ok := groups[jar.key()]; !ok {
+			packageNames = append(packageNames, jar.key())
+		}
+		groups[jar.key()] = append(groups[jar.key()], jar)
+	}
+
+	for _, packageName := range packageNames {
+		var kept, orphan JarProperties
+		for _, jar := range groups[packageName] {
+			main, ok := byFileName[companionOf(jar)]
+			switch {
+			case !ok:
+				if orphan.filePath == "" || compareVersions(orphan.version, jar.version) < 0 {
+					orphan = jar
+				}
+			case keepJars[main.key()].filePath == main.filePath:
+				if kept.filePath == "" {
+					log.Infof("Keeping %v with %v", jar.fileName, main.fileName)
+					kept = jar
+				}
+			}
+		}
+		if kept.filePath == "" {
+			kept = orphan
+		}
+		if kept.filePath != "" {
+			keepJars[packageName] = kept
+			continue
+		}
+		for _, jar := range groups[packageName] {
+			main := byFileName[companionOf(jar)]
+			addFinding(Finding{Type: "duplicate", Severity: severityInfo, Package: packageName,
+				Message: fmt.Sprintf("%v belongs to %v, which is removed", jar.fileName, main.fileName),
+				Files:   []string{jar.filePath}, SuggestedFix: removeFileFix(jar.filePath)})
+		}
+	}
+}
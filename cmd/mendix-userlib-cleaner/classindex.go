@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// classIndexCache is the on-disk inverted index from class names to the JARs
+// providing them. Each JAR is stored with its size and modification time so
+// only changed JARs have to be scanned again.
+type classIndexCache struct {
+	Jars    map[string]cachedJar `json:"jars"`
+	Classes map[string][]string  `json:"classes"`
+}
+
+type cachedJar struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"modTime"`
+}
+
+// cacheDir returns --cache-dir, or the user cache directory if it is
+// writable. In containers without a writable home the cache is skipped.
+func cacheDir() string {
+	dir := viper.GetString("cache-dir")
+	if dir != "" {
+		return dir
+	}
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		log.Debugf("No cache directory available: %v", err)
+		degradeCheck("class index cache", "no cache directory, classes are scanned on every run")
+		return ""
+	}
+	dir = filepath.Join(userCacheDir, "mendix-userlib-cleaner")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Debugf("Not caching, %v is not writable: %v", dir, err)
+		degradeCheck("class index cache", dir+" is not writable, classes are scanned on every run")
+		return ""
+	}
+	return dir
+}
+
+func loadClassIndexCache(cacheFile string) classIndexCache {
+	cache := classIndexCache{Jars: make(map[string]cachedJar), Classes: make(map[string][]string)}
+	if cacheFile == "" {
+		return cache
+	}
+	b, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warningf("Unable to read class index: %v", err)
+			degradeCheck("class index cache", err.Error())
+		}
+		return cache
+	}
+	if err := json.Unmarshal(b, &cache); err != nil || cache.Jars == nil || cache.Classes == nil {
+		log.Warningf("Ignoring corrupt class index %v", cacheFile)
+		degradeCheck("class index cache", "corrupt "+cacheFile+" was ignored")
+		return classIndexCache{Jars: make(map[string]cachedJar), Classes: make(map[string][]string)}
+	}
+	return cache
+}
+
+func saveClassIndexCache(cacheFile string, cache classIndexCache) {
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		log.Warningf("Unable to create cache directory: %v", err)
+		degradeCheck("class index cache", err.Error())
+		return
+	}
+	b, err := json.Marshal(cache)
+	if err != nil {
+		log.Warningf("Unable to encode class index: %v", err)
+		return
+	}
+	if err := writeFileAtomic(cacheFile, b, 0644); err != nil {
+		log.Warningf("Unable to write class index: %v", err)
+		degradeCheck("class index cache", err.Error())
+	}
+}
+
+// indexClasses maps fully qualified class names to every JAR providing them.
+// The index is kept in the cache directory and only JARs whose size or
+// modification time changed since the last run are scanned.
+func indexClasses(jars []JarProperties) map[string][]JarProperties {
+	log.Info("Indexing classes")
+	cacheFile := ""
+	if dir := cacheDir(); dir != "" {
+		cacheFile = filepath.Join(dir, "classindex.json")
+	}
+	cache := loadClassIndexCache(cacheFile)
+
+	// drop JARs that changed or no longer exist
+	stale := make(map[string]bool)
+	for path, cached := range cache.Jars {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() != cached.Size || info.ModTime().UnixNano() != cached.ModTime {
+			stale[path] = true
+			delete(cache.Jars, path)
+		}
+	}
+	if len(stale) > 0 {
+		for className, paths := range cache.Classes {
+			kept := paths[:0]
+			for _, path := range paths {
+				if !stale[path] {
+					kept = append(kept, path)
+				}
+			}
+			if len(kept) == 0 {
+				delete(cache.Classes, className)
+			} else {
+				cache.Classes[className] = kept
+			}
+		}
+	}
+
+	jarsByPath := make(map[string]JarProperties)
+	scanned := 0
+	for _, jar := range jars {
+		path, err := filepath.Abs(jar.filePath)
+		if err != nil {
+			path = jar.filePath
+		}
+		jarsByPath[path] = jar
+		if _, ok := cache.Jars[path]; ok {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Warningf("Unable to index classes of %v: %v", jar.fileName, err)
+			degradeCheck("class analysis", fmt.Sprintf("%v: %v", jar.fileName, err))
+			continue
+		}
+		log.Debugf("Scanning classes of %v", jar.fileName)
+		for _, className := range listClasses(path) {
+			cache.Classes[className] = append(cache.Classes[className], path)
+		}
+		cache.Jars[path] = cachedJar{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		scanned++
+	}
+	if cacheFile != "" && (scanned > 0 || len(stale) > 0) {
+		saveClassIndexCache(cacheFile, cache)
+	}
+	log.Debugf("Scanned %d JAR(s), %d taken from the class index", scanned, len(jarsByPath)-scanned)
+
+	index := make(map[string][]JarProperties)
+	for className, paths := range cache.Classes {
+		for _, path := range paths {
+			if jar, ok := jarsByPath[path]; ok {
+				index[className] = append(index[className], jar)
+			}
+		}
+	}
+	log.Debugf("Indexed %d classes", len(index))
+	return index
+}
+
+func listClasses(filePath string) []string {
+	archive, err := openJar(filePath)
+	if err != nil {
+		log.Warningf("Unable to index classes of %v: %v", filepath.Base(filePath), err)
+		degradeCheck("class analysis", fmt.Sprintf("%v: %v", filepath.Base(filePath), err))
+		return nil
+	}
+	defer archive.Close()
+
+	classNames := []string{}
+	for _, f := range archive.File {
+		if className := classNameFromEntry(f.Name); className != "" {
+			classNames = append(classNames, className)
+		}
+	}
+	return classNames
+}
+
+// classNameFromEntry converts org/example/Foo.class to org.example.Foo and
+// returns an empty string for anything that is not a regular class.
+func classNameFromEntry(name string) string {
+	if !strings.HasSuffix(name, ".class") || strings.HasPrefix(name, "META-INF/") {
+		return ""
+	}
+	name = strings.TrimSuffix(name, ".class")
+	if name == "module-info" || strings.HasSuffix(name, "/package-info") {
+		return ""
+	}
+	return strings.Replace(name, "/", ".", -1)
+}
+
+// whichJar reports every JAR in targetDir providing the given classes.
+func whichJar(targetDir string, mode string, classNames []string) {
+	if len(classNames) == 0 {
+		fatal("Usage: which-jar <fully.qualified.ClassName>...")
+	}
+	filePaths := listAllFiles(targetDir)
+	jars := listAllJars(filePaths, mode)
+	index := indexClasses(jars)
+
+	for _, className := range classNames {
+		className = strings.Replace(strings.TrimSuffix(className, ".class"), "/", ".", -1)
+		providers := index[className]
+		if len(providers) == 0 {
+			log.Warningf("%v is not provided by any JAR", className)
+			continue
+		}
+		log.Infof("%v is provided by %d JAR(s)", className, len(providers))
+		for _, jar := range providers {
+			log.Infof("  %v (%v %v)", jar.filePath, jar.key(), jar.version)
+		}
+	}
+}
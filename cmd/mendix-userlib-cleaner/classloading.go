@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// classpathOrder returns the JARs in the order the runtime puts them on the
+// classpath, assumed to be the userlib files sorted by name. The order is not
+// read from the runtime: Java lists a directory in no defined order, and the
+// tool takes the sorted listing it uses itself. A class is loaded from the
+// first JAR providing it.
+func classpathOrder(jars []JarProperties) []JarProperties {
+	ordered := append([]JarProperties{}, jars...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].fileName < ordered[j].fileName })
+	return ordered
+}
+
+// checkClassShadowing reports which copy of a duplicated package the app uses
+// after cleaning. Duplicates stay when they are held or guarded, and are
+// harmless as long as the first copy on the classpath is the kept one and
+// provides every class; otherwise the app runs on another version than the
+// one kept, or on a mix of versions. Duplicates that are removed are left out.
+func checkClassShadowing(jars []JarProperties, keepJars map[string]JarProperties) {
+	copies := make(map[string][]JarProperties)
+	packageNames := []string{}
+	now := time.Now()
+	for _, jar := range classpathOrder(jars) {
+		keepJar, kept := keepJars[jar.key()]
+		if _, guarded := guardReason(jar.filePath); kept && jar.filePath != keepJar.filePath && !guarded && !isHeld(jar.fullKey(), now) {
+			continue
+		}
+		if _, ok := copies[jar.key()]; !ok {
+			packageNames = append(packageNames, jar.key())
+		}
+		copies[jar.key()] = append(copies[jar.key()], jar)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		if len(copies[packageName]) < 2 {
+			continue
+		}
+		loadedFrom := make(map[string]JarProperties)
+		for _, jar := range copies[packageName] {
+			for _, className := range listClasses(jar.filePath) {
+				if _, ok := loadedFrom[className]; !ok {
+					loadedFrom[className] = jar
+				}
+			}
+		}
+		counts := make(map[string]int)
+		for _, jar := range loadedFrom {
+			counts[jar.filePath]++
+		}
+
+		first := copies[packageName][0]
+		keepJar, kept := keepJars[packageName]
+		if kept && first.filePath != keepJar.filePath {
+			log.Warningf("%v stays and comes first on the classpath, the app runs %v %v rather than kept %v", first.fileName, packageName, first.version, keepJar.version)
+			addFinding(Finding{Type: "class-shadowing", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("%v stays and comes before %v on the classpath, so the app loads %v %v rather than kept %v", first.fileName, keepJar.fileName, packageName, first.version, keepJar.version),
+				Files:        []string{first.filePath, keepJar.filePath},
+				SuggestedFix: &SuggestedFix{Action: "review-file", Target: first.filePath, Detail: "the app runs " + first.version + " as long as this copy stays"}})
+		}
+
+		mixed := []string{}
+		files := []string{}
+		fix := &SuggestedFix{Action: "review-file", Target: packageName, Detail: "keep a single copy"}
+		for _, jar := range copies[packageName] {
+			if counts[jar.filePath] > 0 {
+				mixed = append(mixed, fmt.Sprintf("%d from %v", counts[jar.filePath], jar.fileName))
+				files = append(files, jar.filePath)
+				if kept && jar.filePath != keepJar.filePath && fix.Action != "remove-file" {
+					fix = removeFileFix(jar.filePath)
+				}
+			}
+		}
+		if len(mixed) > 1 {
+			log.Warningf("Classes of %v are loaded from several copies: %v", packageName, strings.Join(mixed, ", "))
+			addFinding(Finding{Type: "mixed-versions", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("The classes of %v are loaded from several versions: %v", packageName, strings.Join(mixed, ", ")),
+				Files:        files,
+				SuggestedFix: fix})
+		} else if !kept || first.filePath == keepJar.filePath {
+			log.Debugf("Duplicates of %v are shadowed by %v", packageName, first.fileName)
+		}
+	}
+}
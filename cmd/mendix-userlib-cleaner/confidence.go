@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Confidence levels of the identification of a JAR: high for metadata like
+// the manifest, pom.properties, pom.xml and fingerprints, medium for
+// module-info.class, low for file names and lowest for the package of the
+// first class found.
+const (
+	confidenceLowest = "lowest"
+	confidenceLow    = "low"
+	confidenceMedium = "medium"
+	confidenceHigh   = "high"
+)
+
+var confidenceLevels = []string{confidenceLowest, confidenceLow, confidenceMedium, confidenceHigh}
+
+func confidenceRank(level string) int {
+	for i, value := range confidenceLevels {
+		if value == level {
+			return i
+		}
+	}
+	return -1
+}
+
+func checkMinConfidence(value string) {
+	if confidenceRank(value) < 0 {
+		fatalf("Unsupported --min-confidence: %v", value)
+	}
+}
+
+// guardLowConfidence keeps duplicates identified with less confidence than
+// --min-confidence, since a file name or class guess may group unrelated
+// libraries. They are reported for review instead of removed.
+func guardLowConfidence(jars []JarProperties, keepJars map[string]JarProperties, minimum string) {
+	for _, jar := range jars {
+		if confidenceRank(jar.confidence) >= confidenceRank(minimum) || keepJars[jar.key()].filePath == jar.filePath {
+			continue
+		}
+		if _, ok := guardReason(jar.filePath); ok {
+			continue
+		}
+		reason := fmt.Sprintf("identified with %v confidence, below --min-confidence %v", jar.confidence, minimum)
+		guardFile(jar.filePath, reason)
+		log.Warningf("Keeping %v, it is %v", jar.fileName, reason)
+		addFinding(Finding{Type: "low-confidence", Severity: severityWarning, Package: jar.key(),
+			Message: fmt.Sprintf("%v looks like a duplicate of kept %v, but it is not removed: it is %v", jar.fileName, keepJars[jar.key()].fileName, reason),
+			Files:   []string{jar.filePath, keepJars[jar.key()].filePath},
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: jar.filePath,
+				Detail: "check that both are the same library, then remove it or lower --min-confidence"}})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+const projectConfigSection = "mendix-cleaner"
+
+// flags holding a path, relative values in the project config are resolved
+// against the project directory, except - for stdin
+var pathFlags = []string{"allow-list", "constraints", "vulnerabilities", "report", "review-queue", "history", "metrics-csv", "import-mxbuild-log", "error-log", "tmp-dir", "cache-dir", "quarantine", "report-dir", "lockfile", "write", "managed-dependencies", "owners", "changelog", "aliases", "triage", "repackage", "image-tar", "files"}
+
+// outputFlags are the files written by a run, placed in --report-dir
+var outputFlags = []string{"report", "review-queue", "metrics-csv", "changelog", "history"}
+
+// findProjectConfig walks up from the target directory and returns the first
+// .mendix/config.yaml it finds, or an empty string.
+func findProjectConfig(targetDir string) string {
+	dir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return ""
+	}
+	for {
+		configPath := filepath.Join(dir, ".mendix", "config.yaml")
+		if info, err := os.Stat(configPath); err == nil && !info.IsDir() {
+			return configPath
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig merges the mendix-cleaner section of the project config
+// into viper. Flags given on the command line take precedence over the config,
+// which takes precedence over the flag defaults.
+func loadProjectConfig(configPath string) error {
+	b, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	config := struct {
+		Cleaner map[string]interface{} `yaml:"mendix-cleaner"`
+	}{}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return err
+	}
+
+	projectDir := filepath.Dir(filepath.Dir(configPath))
+	settings := make(map[string]interface{})
+	for key, value := range config.Cleaner {
+		if key == "target" || pflag.CommandLine.Lookup(key) == nil {
+			return fmt.Errorf("unsupported setting in %v section: %v", projectConfigSection, key)
+		}
+		if path, ok := value.(string); ok && contains(pathFlags, key) && path != "" && path != "-" && !filepath.IsAbs(path) {
+			value = filepath.Join(projectDir, path)
+		}
+		if key == "mode" {
+			if mode, ok := value.(string); ok && !contains([]string{"auto", "strict"}, mode) && !filepath.IsAbs(mode) {
+				value = filepath.Join(projectDir, mode)
+			}
+		}
+		settings[key] = value
+	}
+	return viper.MergeConfigMap(settings)
+}
+
+// applyReportDir resolves relative output paths against the report directory,
+// so a run works with a read-only target, and writes report.json there by
+// default.
+func applyReportDir(reportDir string) {
+	if err := os.MkdirAll(reportDir, 0755); err != nil {
+		fatalf("Unable to create report directory: %v", err)
+	}
+	if viper.GetString("report") == "" {
+		viper.Set("report", "report.json")
+	}
+	for _, name := range outputFlags {
+		if value := viper.GetString(name); value != "" && !filepath.IsAbs(value) {
+			viper.Set(name, filepath.Join(reportDir, value))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// loadConstraints reads a YAML file with the versions the runtime is
+// compatible with, e.g.
+//
+//	org.apache.httpcomponents.httpclient: ">=4.5.13 <5"
+//
+// Unlike the allow-list, packages without a constraint are not affected.
+func loadConstraints(path string) map[string]versionRange {
+	constraints := loadVersionRanges(path, "constraints")
+	log.Infof("Loaded %d constraints from %v", len(constraints), path)
+	return constraints
+}
+
+// applyConstraints keeps the newest version within the range of each
+// constrained package. Versions outside of the range are reported; when no
+// version satisfies the constraint the kept JAR stays, as the app needs one.
+func applyConstraints(jars []JarProperties, keepJars map[string]JarProperties, constraints map[string]versionRange) {
+	packageNames := []string{}
+	for packageName := range constraints {
+		if _, ok := keepJars[packageName]; ok {
+			packageNames = append(packageNames, packageName)
+		}
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		vr := constraints[packageName]
+		kept := keepJars[packageName]
+		best, found := newestInRange(jars, packageName, vr)
+		if !found {
+			log.Warningf("No version of %v satisfies %v, keeping %v", packageName, vr, kept.fileName)
+			addFinding(Finding{Type: "constraint-violation", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("No version of %v satisfies the constraint %v, %v is kept", packageName, vr, kept.version),
+				Files:        []string{kept.filePath},
+				SuggestedFix: &SuggestedFix{Action: "update-module", Target: packageName, Detail: "install a version matching " + vr.String()}})
+			continue
+		}
+		if best.filePath != kept.filePath {
+			log.Infof("Keeping %v over %v to satisfy %v", best.fileName, kept.fileName, vr)
+			keepJars[packageName] = best
+		}
+		for _, jar := range jars {
+			if jar.key() != packageName || vr.contains(jar.version) {
+				continue
+			}
+			// the version that would have been kept is the one to look at
+			severity := severityInfo
+			if jar.filePath == kept.filePath {
+				severity = severityWarning
+			}
+			addFinding(Finding{Type: "constraint-violation", Severity: severity, Package: packageName,
+				Message:      fmt.Sprintf("Version %v of %v is outside of the constraint %v", jar.version, packageName, vr),
+				Files:        []string{jar.filePath},
+				SuggestedFix: removeFileFix(jar.filePath)})
+		}
+	}
+}
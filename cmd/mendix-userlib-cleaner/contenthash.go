@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// applyContentHashes treats kept JARs with identical bytes as duplicates,
+// whatever their metadata says, like a copy renamed to vendor-lib.jar that
+// is identified differently or not at all. Only files of the same size are
+// hashed. The best identified copy stays kept, the packages of the others
+// are dropped so their files are removed.
+func applyContentHashes(keepJars map[string]JarProperties) {
+	bySize := make(map[int64][]JarProperties)
+	for _, jar := range keepJars {
+		bySize[jar.size] = append(bySize[jar.size], jar)
+	}
+
+	byHash := make(map[string][]JarProperties)
+	for _, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+		for _, jar := range candidates {
+			hash, err := fileSHA256(jar.filePath)
+			if err != nil {
+				log.Warningf("Unable to hash %v: %v", jar.filePath, err)
+				continue
+			}
+			byHash[hash] = append(byHash[hash], jar)
+		}
+	}
+
+	hashes := []string{}
+	for hash, copies := range byHash {
+		if len(copies) > 1 {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	for _, hash := range hashes {
+		copies := byHash[hash]
+		sort.Slice(copies, func(i, j int) bool {
+			return preferredCopy(copies[i], copies[j])
+		})
+		kept := copies[0]
+		for _, jar := range copies[1:] {
+			log.Infof("%v is identical to %v (SHA-256 %v)", jar.fileName, kept.fileName, hash)
+			delete(keepJars, jar.key())
+			addFinding(Finding{Type: "identical-content", Severity: severityInfo, Package: jar.key(),
+				Message:      fmt.Sprintf("%v has the same content as kept %v", jar.fileName, kept.fileName),
+				Files:        []string{jar.filePath},
+				SuggestedFix: removeFileFix(jar.filePath)})
+		}
+	}
+}
+
+// preferredCopy orders identical copies: identified ones first, then those
+// named after their version, then by file name.
+func preferredCopy(a JarProperties, b JarProperties) bool {
+	if isUnidentified(a) != isUnidentified(b) {
+		return !isUnidentified(a)
+	}
+	namedA := a.version != "" && strings.HasSuffix(trimArchiveExtension(a.fileName), a.version)
+	namedB := b.version != "" && strings.HasSuffix(trimArchiveExtension(b.fileName), b.version)
+	if namedA != namedB {
+		return namedA
+	}
+	return a.fileName < b.fileName
+}
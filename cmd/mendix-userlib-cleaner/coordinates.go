@@ -0,0 +1,137 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Coordinates identify an artifact the way Maven does. Every parser fills them
+// in, and duplicates are detected, reported and looked up by their key.
+type Coordinates struct {
+	group      string
+	artifact   string
+	version    string
+	classifier string
+	packaging  string
+	// bundle is the OSGi Bundle-SymbolicName, if any
+	bundle string
+	// unique is the file path of JARs left out of deduplication, like
+	// shaded and unidentified JARs. It is the key as it is, since paths
+	// differing in case are different files on most file systems.
+	unique string
+	// alias is the key of the library a relocated artifact is known as
+	alias string
+}
+
+// Identities select which part of the coordinates makes JARs duplicates.
+const (
+	identityArtifact      = "artifact"
+	identityGroupArtifact = "group-artifact"
+	identityBundle        = "bundle"
+)
+
+var identity = identityGroupArtifact
+
+func setIdentity(value string) {
+	if !contains([]string{identityArtifact, identityGroupArtifact, identityBundle}, value) {
+		fatalf("Unsupported --identity: %v", value)
+	}
+	identity = value
+}
+
+// key identifies the artifact regardless of its version and case, e.g.
+// org.apache.velocity.velocity-engine-core.
+func (c Coordinates) key() string {
+	if c.unique != "" {
+		return c.unique
+	}
+	return normalizeKey(c.rawKey())
+}
+
+// fullKey is the key by group and artifact, whatever the --identity. The
+// configuration names packages this way: the allow-list, constraints,
+// vulnerabilities, --keep, --hold and --critical-packages.
+func (c Coordinates) fullKey() string {
+	if c.unique != "" {
+		return c.unique
+	}
+	return normalizeKey(c.rawKeyBy(identityGroupArtifact))
+}
+
+// rawKey is the key as found in the metadata. With --identity artifact the
+// group is ignored, so vendor-renamed JARs match; with --identity bundle the
+// bundle symbolic name is used where present.
+func (c Coordinates) rawKey() string {
+	return c.rawKeyBy(identity)
+}
+
+func (c Coordinates) rawKeyBy(by string) string {
+	key := c.group + "." + c.artifact
+	switch {
+	case c.unique != "":
+		return c.unique
+	case c.alias != "":
+		key = c.alias
+	case by == identityBundle && c.bundle != "":
+		key = c.bundle
+	case by == identityArtifact || c.group == "":
+		key = c.artifact
+	}
+	// companions like sources JARs are deduplicated apart from their JAR
+	if isCompanionClassifier(c.classifier) {
+		key += ":" + c.classifier
+	}
+	return key
+}
+
+// normalizeKey makes package names from the metadata, the command line and
+// configuration files comparable. Heuristically parsed JARs sometimes differ
+// only in case, like Org.Apache.Commons and org.apache.commons.
+func normalizeKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// logCaseVariants makes the case-insensitive grouping visible.
+func logCaseVariants(jars []JarProperties) {
+	variants := make(map[string][]string)
+	for _, jar := range jars {
+		if !contains(variants[jar.key()], jar.rawKey()) {
+			variants[jar.key()] = append(variants[jar.key()], jar.rawKey())
+		}
+	}
+	for key, names := range variants {
+		if len(names) > 1 {
+			sort.Strings(names)
+			log.Infof("Grouping case variants %v as %v", strings.Join(names, ", "), key)
+		}
+	}
+}
+
+// setName fills in group and artifact from a dotted name like a bundle
+// symbolic name, splitting at the last dot: org.apache.velocity becomes group
+// org.apache and artifact velocity.
+func (c *Coordinates) setName(name string) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		c.group, c.artifact = "", name
+		return
+	}
+	c.group, c.artifact = name[:i], name[i+1:]
+}
+
+// setFileDetails derives packaging and classifier from the file name, e.g.
+// velocity-1.7-sources.jar has packaging jar and classifier sources.
+// Classifiers of companions like sources are recognized in any file name.
+func (c *Coordinates) setFileDetails(fileName string) {
+	extension := filepath.Ext(fileName)
+	c.packaging = strings.TrimPrefix(extension, ".")
+	prefix := c.artifact + "-" + c.version + "-"
+	base := strings.TrimSuffix(fileName, extension)
+	if c.version != "" && strings.HasPrefix(base, prefix) {
+		c.classifier = strings.TrimPrefix(base, prefix)
+	} else if c.classifier == "" {
+		// metadata of companions often names another artifact or version
+		c.classifier = companionClassifier(fileName)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestKeyOfUnidentifiedJarsKeepsCase(t *testing.T) {
+	upper := Coordinates{artifact: "userlib/Foo.jar", unique: "userlib/Foo.jar"}
+	lower := Coordinates{artifact: "userlib/foo.jar", unique: "userlib/foo.jar"}
+	if upper.key() == lower.key() {
+		t.Errorf("Foo.jar and foo.jar share the key %v", upper.key())
+	}
+	if got := (Coordinates{group: "Org.Apache", artifact: "Commons"}).key(); got != (Coordinates{group: "org.apache", artifact: "commons"}).key() {
+		t.Errorf("case variants of a package got different keys, %v", got)
+	}
+}
+
+func TestConfigurationMatchesFullKeyWithIdentity(t *testing.T) {
+	defer func() { identity = identityGroupArtifact }()
+	defer resetFindings()
+	defer viper.Reset()
+	identity = identityArtifact
+	viper.Set("critical-packages", []string{"org.postgresql"})
+
+	older := JarProperties{filePath: "userlib/httpclient-4.5.10.jar", fileName: "httpclient-4.5.10.jar",
+		Coordinates: Coordinates{group: "org.apache.httpcomponents", artifact: "httpclient", version: "4.5.10"}}
+	newer := JarProperties{filePath: "userlib/httpclient-4.5.14.jar", fileName: "httpclient-4.5.14.jar",
+		Coordinates: Coordinates{group: "org.apache.httpcomponents", artifact: "httpclient", version: "4.5.14"}}
+	driver := JarProperties{filePath: "userlib/postgresql-42.7.1.jar", fileName: "postgresql-42.7.1.jar",
+		Coordinates: Coordinates{group: "org.postgresql", artifact: "postgresql", version: "42.7.1"}}
+	jars := []JarProperties{older, newer, driver}
+	if newer.key() != "httpclient" {
+		t.Fatalf("key with --identity artifact = %v, want httpclient", newer.key())
+	}
+
+	keepJars := map[string]JarProperties{newer.key(): newer, driver.key(): driver}
+	allowList := map[string]versionRange{}
+	for packageName, text := range map[string]string{"org.apache.httpcomponents.httpclient": "<4.5.12", "org.postgresql.postgresql": "*"} {
+		vr, _ := parseVersionRange(text)
+		allowList[packageName] = vr
+	}
+	applyAllowList(jars, keepJars, rangesByIdentity(jars, allowList))
+	if keepJars[newer.key()].filePath != older.filePath {
+		t.Errorf("kept %v, want the allowed %v", keepJars[newer.key()].fileName, older.fileName)
+	}
+	if _, ok := keepJars[driver.key()]; !ok {
+		t.Errorf("allowed %v was dropped", driver.fileName)
+	}
+
+	applyKeepOverrides(jars, keepJars, map[string]string{"org.apache.httpcomponents.httpclient": "4.5.14"})
+	if keepJars[newer.key()].filePath != newer.filePath {
+		t.Errorf("--keep kept %v, want %v", keepJars[newer.key()].fileName, newer.fileName)
+	}
+
+	if !isCriticalPackage(driver.fullKey()) {
+		t.Errorf("%v is not critical with --identity artifact", driver.fileName)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultCriticalPackages are connectors the Mendix runtime depends on, like
+// database drivers and SAML/OIDC libraries. The tool only advises on them.
+var defaultCriticalPackages = []string{
+	"org.postgresql", "com.microsoft.sqlserver", "com.mysql", "mysql", "org.mariadb", "com.oracle.database", "oracle.jdbc", "com.ibm.db2", "org.hsqldb",
+	"org.opensaml", "net.shibboleth", "org.apache.santuario", "com.onelogin", "com.nimbusds", "org.pac4j",
+}
+
+// isCriticalPackage tells whether a package key equals or lies below one of
+// the --critical-packages. JARs are matched by their fullKey.
+func isCriticalPackage(key string) bool {
+	for _, prefix := range viper.GetStringSlice("critical-packages") {
+		prefix = normalizeKey(prefix)
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirmRemoval asks whether a file of a critical package may be removed.
+// This cannot be answered in advance. Without a terminal or in --stdio mode
+// the answer is no.
+func confirmRemoval(filePath string) bool {
+	if viper.GetBool("stdio") {
+		// stdin carries the protocol
+		return false
+	}
+	fmt.Fprintf(stderr, "%v belongs to a critical package. Remove it? [y/N] ", filePath)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(stderr)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// criticalPackageFinding advises on the removal of a critical package's JAR,
+// stressing when it downgrades the package.
+func criticalPackageFinding(jar JarProperties, keepJar JarProperties) Finding {
+	message := fmt.Sprintf("%v is a critical package, removing %v needs confirmation", jar.key(), jar.fileName)
+	if keepJar.filePath == "" {
+		message = fmt.Sprintf("%v is a critical package, removing %v leaves no version and needs confirmation", jar.key(), jar.fileName)
+	} else if compareVersions(jar.version, keepJar.version) > 0 {
+		message = fmt.Sprintf("%v is a critical package, removing %v downgrades it to %v and needs confirmation", jar.key(), jar.fileName, keepJar.version)
+	}
+	return Finding{Type: "critical-package", Severity: severityWarning, Package: jar.key(), Message: message, Files: []string{jar.filePath},
+		SuggestedFix: &SuggestedFix{Action: "review-file", Target: jar.filePath, Detail: "verify the app with the kept version before confirming"}}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// DegradedCheck is an analysis that was skipped or incomplete because an
+// optional data source, like a cache or attribution markers, failed.
+type DegradedCheck struct {
+	Check   string   `json:"check"`
+	Reasons []string `json:"reasons"`
+}
+
+var (
+	degradedMutex sync.Mutex
+	degraded      = make(map[string][]string)
+)
+
+// degradeCheck records why a check is incomplete. The run continues.
+func degradeCheck(check string, reason string) {
+	degradedMutex.Lock()
+	defer degradedMutex.Unlock()
+	log.Debugf("Degraded %v: %v", check, reason)
+	if !contains(degraded[check], reason) {
+		degraded[check] = append(degraded[check], reason)
+	}
+}
+
+func resetDegradedChecks() {
+	degradedMutex.Lock()
+	defer degradedMutex.Unlock()
+	degraded = make(map[string][]string)
+}
+
+func degradedChecks() []DegradedCheck {
+	degradedMutex.Lock()
+	defer degradedMutex.Unlock()
+	checks := []DegradedCheck{}
+	for check, reasons := range degraded {
+		checks = append(checks, DegradedCheck{check, append([]string{}, reasons...)})
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Check < checks[j].Check })
+	return checks
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// deploymentDir returns the deployment folder of the project the target
+// belongs to: the deployment folder containing the target, or the one next to
+// the project userlib.
+func deploymentDir(targetDir string) string {
+	dir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return ""
+	}
+	for current := dir; ; current = filepath.Dir(current) {
+		if filepath.Base(current) == "deployment" {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	return filepath.Join(filepath.Dir(dir), "deployment")
+}
+
+func isInside(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// runningEvidence returns the pid or lock file showing that the app is
+// running from the deployment folder, or an empty string.
+func runningEvidence(deploymentDir string) string {
+	evidence := ""
+	walkDeployment(deploymentDir, 0, func(path string, info os.FileInfo) bool {
+		name := strings.ToLower(info.Name())
+		switch {
+		case strings.HasSuffix(name, ".pid"):
+			if b, err := ioutil.ReadFile(path); err == nil {
+				if pid, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil && processRunning(pid) {
+					evidence = path
+				}
+			}
+		case strings.HasSuffix(name, ".lck") || strings.HasSuffix(name, ".lock"):
+			// the embedded database holds a lock file while the runtime runs
+			evidence = path
+		}
+		return evidence == ""
+	})
+	return evidence
+}
+
+// walkDeployment visits the files in the top levels of the deployment folder
+// until visit returns false. The userlib copies are not descended into.
+func walkDeployment(dir string, depth int, visit func(string, os.FileInfo) bool) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return true
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		if f.IsDir() {
+			if depth < 3 && f.Name() != "userlib" && !walkDeployment(path, depth+1, visit) {
+				return false
+			}
+		} else if !visit(path, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// finding the process only succeeds if it exists
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// checkRunningDeployment keeps a running app intact. Cleaning inside its
+// deployment folder is turned into a dry run, cleaning the project userlib
+// goes ahead and reaches the deployment on the next run of the app.
+func checkRunningDeployment(targetDir string, clean bool) bool {
+	deployment := deploymentDir(targetDir)
+	evidence := runningEvidence(deployment)
+	if evidence == "" {
+		return clean
+	}
+	abs, _ := filepath.Abs(targetDir)
+	if isInside(deployment, abs) {
+		if clean {
+			log.Warningf("The app is running from %v (see %v). Not cleaning the deployment folder now, stop the app and run again or clean the project userlib instead", deployment, evidence)
+		}
+		return false
+	}
+	if clean {
+		log.Infof("The app is running from %v (see %v). Only %v is cleaned, the deployment folder follows on the next run of the app", deployment, evidence, targetDir)
+	}
+	return clean
+}
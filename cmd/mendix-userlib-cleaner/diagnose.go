@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type classError struct {
+	errorType string
+	className string
+}
+
+var classErrorPatterns = []*regexp.Regexp{
+	// java.lang.NoClassDefFoundError: org/apache/http/HttpEntity
+	// java.lang.NoClassDefFoundError: Could not initialize class org.apache.poi.POIXMLTypeLoader
+	regexp.MustCompile(`(NoClassDefFoundError): (?:Could not initialize class )?([\w$./]+)`),
+	// java.lang.ClassNotFoundException: org.apache.http.HttpEntity
+	regexp.MustCompile(`(ClassNotFoundException): ([\w$.]+)`),
+	// java.lang.NoSuchMethodError: 'void org.example.Foo.bar(java.lang.String)'
+	// java.lang.NoSuchMethodError: org.example.Foo.bar(Ljava/lang/String;)V
+	regexp.MustCompile(`(NoSuchMethodError): '?(?:[\w$.\[\]<>]+ )?([\w$.]+)\.[\w$<>]+\(`),
+	// java.lang.LinkageError: loader constraint violation: ... for class org.example.Foo
+	// java.lang.LinkageError: attempted duplicate class definition for name: "org/example/Foo"
+	regexp.MustCompile(`(LinkageError): .*?(?:class|name:?) "?([\w$]+(?:[./][\w$]+)+)"?`),
+}
+
+func parseClassErrors(errorLog string) []classError {
+	b, err := ioutil.ReadFile(errorLog)
+	if err != nil {
+		fatalf("Unable to read error log: %v", err)
+	}
+
+	seen := make(map[classError]bool)
+	classErrors := []classError{}
+	for _, line := range strings.Split(string(b), "\n") {
+		for _, re := range classErrorPatterns {
+			match := re.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			className := strings.Replace(match[2], "/", ".", -1)
+			ce := classError{errorType: match[1], className: strings.TrimSuffix(className, ".")}
+			if !seen[ce] {
+				seen[ce] = true
+				classErrors = append(classErrors, ce)
+			}
+		}
+	}
+	log.Infof("Found %d distinct class loading error(s) in %v", len(classErrors), errorLog)
+	return classErrors
+}
+
+// diagnose cross-references class loading errors from a runtime log with the
+// classes provided by the JARs in targetDir.
+func diagnose(targetDir string, mode string, errorLog string, policy Policy) {
+	if errorLog == "" {
+		fatal("Use --error-log to point to the log containing the errors")
+	}
+	classErrors := parseClassErrors(errorLog)
+	if len(classErrors) == 0 {
+		log.Info("Nothing to diagnose")
+		return
+	}
+
+	filePaths := listAllFiles(targetDir)
+	jars := listAllJars(filePaths, mode)
+	keepJars := decideJarsToKeep(jars, mode, policy)
+	index := indexClasses(jars)
+
+	for _, ce := range classErrors {
+		providers := index[ce.className]
+		switch {
+		case len(providers) == 0:
+			log.Warningf("%v %v: no JAR in userlib provides this class", ce.errorType, ce.className)
+			if candidates := jarsProvidingPackage(index, ce.className); len(candidates) > 0 {
+				log.Warningf("  JARs providing the same Java package: %v. A different version of one of them may be required", strings.Join(candidates, ", "))
+			} else {
+				log.Warningf("  The library is missing. Add the JAR providing it or check whether it was removed")
+			}
+		case len(providers) > 1:
+			log.Warningf("%v %v: provided by %d JARs, duplicates are the likely cause", ce.errorType, ce.className, len(providers))
+			for _, jar := range providers {
+				if keepJars[jar.key()].filePath == jar.filePath {
+					log.Warningf("  %v (%v %v) is kept", jar.fileName, jar.key(), jar.version)
+				} else {
+					log.Warningf("  %v (%v %v) would be removed by a clean", jar.fileName, jar.key(), jar.version)
+				}
+			}
+		default:
+			jar := providers[0]
+			if ce.errorType == "NoSuchMethodError" || ce.errorType == "LinkageError" {
+				log.Warningf("%v %v: only provided by %v (%v %v). The calling library probably expects another version of it", ce.errorType, ce.className, jar.fileName, jar.key(), jar.version)
+			} else {
+				log.Warningf("%v %v: provided by %v (%v %v). Check whether one of its dependencies is missing", ce.errorType, ce.className, jar.fileName, jar.key(), jar.version)
+			}
+		}
+	}
+}
+
+func jarsProvidingPackage(index map[string][]JarProperties, className string) []string {
+	i := strings.LastIndex(className, ".")
+	if i < 0 {
+		return nil
+	}
+	javaPackage := className[:i+1]
+	fileNames := make(map[string]bool)
+	for indexed, jars := range index {
+		if strings.HasPrefix(indexed, javaPackage) && !strings.Contains(indexed[len(javaPackage):], ".") {
+			for _, jar := range jars {
+				fileNames[jar.fileName] = true
+			}
+		}
+	}
+	names := []string{}
+	for name := range fileNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var severityOrder = []string{severityCritical, severityWarning, severityInfo}
+
+// doctor runs every check on targetDir without changing anything and prints
+// the findings by priority, each type with a command or action to fix it.
+func doctor(targetDir string, mode string, policy Policy) {
+	// nothing is removed, but the dry run validates paths like a real one
+	guardTarget(targetDir)
+	filePaths := listAllFiles(targetDir)
+	jars := listAllJars(filePaths, mode)
+	keepJars := decideJarsToKeep(jars, mode, policy)
+	runChecks(jars, keepJars)
+	checkOwners(jars, keepJars, policy)
+	if managed := managedDependencies(targetDir, findProjectConfig(targetDir)); managed != "" {
+		reconcileManagedDependencies(managed, jars)
+	}
+	jars = restrictToModules(jars, viper.GetStringSlice("module"))
+	cleanJars(false, filePaths, jars, keepJars)
+	reportSkippedFiles(false)
+
+	groups := make(map[string][]Finding)
+	for _, finding := range allFindings() {
+		groups[finding.Severity+" "+finding.Type] = append(groups[finding.Severity+" "+finding.Type], finding)
+	}
+	keys := []string{}
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := groups[keys[i]][0], groups[keys[j]][0]
+		if a.Severity != b.Severity {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+
+	if len(keys) == 0 {
+		log.Infof("Health: %d JAR(s) in %v, no issues found", len(jars), targetDir)
+		return
+	}
+	log.Infof("Health: %d JAR(s) in %v", len(jars), targetDir)
+	for i, key := range keys {
+		findings := groups[key]
+		log.Infof("  %d. [%v] %d x %v %v, e.g. %v", i+1, findings[0].Severity, len(findings), findings[0].Rule, findings[0].Type, findings[0].Message)
+		if fix := remediation(targetDir, findings[0]); fix != "" {
+			log.Infof("     Fix: %v", fix)
+		}
+	}
+}
+
+func severityRank(severity string) int {
+	for i, s := range severityOrder {
+		if s == severity {
+			return i
+		}
+	}
+	return len(severityOrder)
+}
+
+// remediation turns the suggested fix of a finding into a command or action.
+func remediation(targetDir string, finding Finding) string {
+	if finding.SuggestedFix == nil {
+		return ""
+	}
+	command := fmt.Sprintf("%v --target %v", filepath.Base(os.Args[0]), targetDir)
+	fix := finding.SuggestedFix
+	switch fix.Action {
+	case "remove-file":
+		return command + " --clean"
+	case "add-pin":
+		return fmt.Sprintf("%v --keep %v (%v)", command, fix.Target, fix.Detail)
+	case "update-module":
+		return fmt.Sprintf("update the module providing %v in Studio Pro, %v", fix.Target, fix.Detail)
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v %v, %v", strings.Replace(fix.Action, "-file", "", 1), fix.Target, fix.Detail))
+	}
+}
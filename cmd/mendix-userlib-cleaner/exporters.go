@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ExportRun is everything an exporter gets to see of a finished run.
+type ExportRun struct {
+	Report  Report
+	Jars    []JarProperties
+	Project string
+}
+
+// Exporter emits the result of a run in one format or to one destination.
+// Several exporters can run for the same scan, see --exporter.
+type Exporter interface {
+	Name() string
+	Export(run ExportRun) error
+}
+
+var exporterFactories = map[string]func() Exporter{
+	"console": func() Exporter { return consoleExporter{} },
+	"json":    func() Exporter { return jsonExporter{} },
+	"html":    func() Exporter { return htmlExporter{} },
+	"sarif":   func() Exporter { return sarifExporter{} },
+	"metrics": func() Exporter { return metricsExporter{} },
+	"webhook": func() Exporter { return httpExporter{"webhook", http.MethodPost, "webhook-url"} },
+	"s3":      func() Exporter { return httpExporter{"s3", http.MethodPut, "s3-url"} },
+}
+
+// selectExporters validates --exporter before the scan starts. Without it,
+// --report and --metrics-csv select the json and metrics exporters.
+func selectExporters(names []string) []Exporter {
+	if len(names) == 0 {
+		if viper.GetString("report") != "" {
+			names = append(names, "json")
+		}
+		if viper.GetString("metrics-csv") != "" {
+			names = append(names, "metrics")
+		}
+	}
+	exporters := []Exporter{}
+	for _, name := range names {
+		factory, ok := exporterFactories[name]
+		if !ok {
+			fatalf("Unknown exporter: %v", name)
+		}
+		exporters = append(exporters, factory())
+	}
+	return exporters
+}
+
+// export runs every exporter, also when an earlier one failed. Failures are
+// listed in the degraded checks of the summary.
+func export(exporters []Exporter, run ExportRun) {
+	for _, exporter := range exporters {
+		if err := exporter.Export(run); err != nil {
+			log.Errorf("Exporter %v failed: %v", exporter.Name(), err)
+			degradeCheck("export "+exporter.Name(), err.Error())
+		}
+	}
+}
+
+// exportPath returns the path of a file export with the given extension:
+// --report with its extension replaced, or report<ext> in --report-dir.
+func exportPath(extension string) string {
+	path := viper.GetString("report")
+	if path == "" {
+		path = filepath.Join(viper.GetString("report-dir"), "report.json")
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + extension
+}
+
+type consoleExporter struct{}
+
+func (consoleExporter) Name() string { return "console" }
+
+func (consoleExporter) Export(run ExportRun) error {
+	for _, finding := range run.Report.Findings {
+		log.Infof("[%v] %v %v: %v", finding.Severity, finding.Rule, finding.Type, finding.Message)
+	}
+	return nil
+}
+
+type jsonExporter struct{}
+
+func (jsonExporter) Name() string { return "json" }
+
+func (jsonExporter) Export(run ExportRun) error {
+	path := viper.GetString("report")
+	if path == "" {
+		path = exportPath(".json")
+	}
+	return writeReport(path, run.Report)
+}
+
+type htmlExporter struct{}
+
+func (htmlExporter) Name() string { return "html" }
+
+func (htmlExporter) Export(run ExportRun) error {
+	path := exportPath(".html")
+	combined := CombinedReport{Totals: ReportTotals{Reports: 1, Jars: len(run.Report.Jars), Findings: make(map[string]int)}, Reports: []Report{run.Report}}
+	for _, jar := range run.Report.Jars {
+		combined.Totals.Size += jar.Size
+	}
+	for _, finding := range run.Report.Findings {
+		combined.Totals.Findings[finding.Severity]++
+	}
+	if err := writeFileAtomic(path, renderHTMLReport(combined), 0644); err != nil {
+		return err
+	}
+	log.Infof("HTML report written to %v", path)
+	return nil
+}
+
+type metricsExporter struct{}
+
+func (metricsExporter) Name() string { return "metrics" }
+
+func (metricsExporter) Export(run ExportRun) error {
+	path := viper.GetString("metrics-csv")
+	if path == "" {
+		return fmt.Errorf("use --metrics-csv to set the path of the CSV file")
+	}
+	return appendMetrics(path, run.Project, run.Jars)
+}
+
+// httpExporter sends the JSON report to a URL, a webhook or a presigned S3
+// upload URL.
+type httpExporter struct {
+	name   string
+	method string
+	urlKey string
+}
+
+func (e httpExporter) Name() string { return e.name }
+
+func (e httpExporter) Export(run ExportRun) error {
+	url := viper.GetString(e.urlKey)
+	if url == "" {
+		return fmt.Errorf("use --%v to set the URL", e.urlKey)
+	}
+	b, err := json.Marshal(run.Report)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(e.method, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	client := http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("%v answered %v", request.URL.Host, response.Status)
+	}
+	log.Infof("Report sent to %v", request.URL.Host)
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	fileListOnce sync.Once
+	fileList     []string
+)
+
+// readFileList reads a newline-separated list of paths from a file, or from
+// stdin for -, e.g. the output of git diff --name-only. It is read once, also
+// with several targets.
+func readFileList(source string) []string {
+	fileListOnce.Do(func() {
+		var reader io.Reader = os.Stdin
+		if source != "-" {
+			file, err := os.Open(source)
+			if err != nil {
+				fatalf("Unable to read file list: %v", err)
+			}
+			defer file.Close()
+			reader = file
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				fileList = append(fileList, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fatalf("Unable to read file list: %v", err)
+		}
+	})
+	return fileList
+}
+
+// listGivenFiles returns the files of --files inside targetDir instead of
+// listing the directory. Paths are relative to the working directory. Files
+// that no longer exist, like those deleted in a diff, and those ignored by a
+// .cleanerignore are skipped.
+func listGivenFiles(targetDir string, source string) []string {
+	log.Infof("Reading the files to analyze in %v from %v", targetDir, source)
+	absTarget, err := filepath.Abs(targetDir)
+	if err != nil {
+		fatal(err)
+	}
+	absTarget = longPath(absTarget)
+	rules := make(map[string][]ignoreRule)
+	filePaths := []string{}
+	for _, filePath := range readFileList(source) {
+		abs, err := filepath.Abs(filePath)
+		abs = longPath(abs)
+		if err != nil || !isInside(absTarget, abs) {
+			log.Debugf("%v is not in %v", filePath, targetDir)
+			continue
+		}
+		info, err := os.Lstat(abs)
+		if err != nil {
+			skipFile(filePath, "does not exist")
+			continue
+		}
+		if isSymlink(info) {
+			resolved, ok := resolveSymlink(abs)
+			if !ok {
+				continue
+			}
+			info = resolved
+		}
+		if info.IsDir() {
+			skipFile(filePath, "directory")
+			continue
+		}
+		if isIgnoredPath(absTarget, abs, rules) {
+			skipFile(filePath, "matches "+ignoreFileName)
+			continue
+		}
+		filePaths = append(filePaths, longPath(filePath))
+	}
+	return filePaths
+}
+
+// ignoreScopes returns the .cleanerignore files applying to dir, from
+// targetDir down, with the rules read so far in cache. Both paths are
+// absolute.
+func ignoreScopes(targetDir string, dir string, cache map[string][]ignoreRule) []ignoreScope {
+	dirs := []string{}
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		dirs = append([]string{current}, dirs...)
+		if current == targetDir || filepath.Dir(current) == current {
+			break
+		}
+	}
+	scopes := []ignoreScope{}
+	for _, current := range dirs {
+		rules, ok := cache[current]
+		if !ok {
+			rules = loadIgnoreRules(current)
+			cache[current] = rules
+		}
+		if len(rules) > 0 {
+			scopes = append(scopes, ignoreScope{dir: current, rules: rules})
+		}
+	}
+	return scopes
+}
+
+// isIgnoredPath tells whether a file or one of its directories below
+// targetDir is ignored, as listing the target would skip it.
+func isIgnoredPath(targetDir string, filePath string, cache map[string][]ignoreRule) bool {
+	isDir := false
+	for current := filePath; current != targetDir; current = filepath.Dir(current) {
+		if isIgnoredIn(ignoreScopes(targetDir, filepath.Dir(current), cache), current, isDir) {
+			return true
+		}
+		isDir = true
+	}
+	return false
+}
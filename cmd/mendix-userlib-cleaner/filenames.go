@@ -0,0 +1,45 @@
+package main
+
+import (
+	"regexp"
+)
+
+// fileNamePatterns are the --filename-pattern regular expressions for
+// organization-specific file names, tried before the name-version.jar
+// convention.
+var fileNamePatterns []*regexp.Regexp
+
+func setFileNamePatterns(values []string) {
+	fileNamePatterns = nil
+	for _, value := range values {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			fatalf("Invalid --filename-pattern %q: %v", value, err)
+		}
+		if pattern.SubexpIndex("version") < 0 {
+			fatalf("Invalid --filename-pattern %q, it needs a (?P<version>...) group", value)
+		}
+		fileNamePatterns = append(fileNamePatterns, pattern)
+	}
+}
+
+// matchFileName returns the artifact, version and classifier named by the
+// groups of the first --filename-pattern matching the file name, e.g.
+// foo_bar and 2.3.1 of foo_bar_v2.3.1_patched.jar for
+// ^(?P<artifact>.+)_v(?P<version>[0-9.]+)(_(?P<classifier>\w+))?\.jar$.
+func matchFileName(fileName string) (string, string, string, bool) {
+	for _, pattern := range fileNamePatterns {
+		match := pattern.FindStringSubmatch(fileName)
+		if match == nil {
+			continue
+		}
+		group := func(name string) string {
+			if i := pattern.SubexpIndex(name); i >= 0 {
+				return match[i]
+			}
+			return ""
+		}
+		return group("artifact"), group("version"), group("classifier"), true
+	}
+	return "", "", "", false
+}
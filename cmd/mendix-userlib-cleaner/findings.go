@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync"
+)
+
+const (
+	severityCritical = "critical"
+	severityWarning  = "warning"
+	severityInfo     = "info"
+)
+
+// SuggestedFix is a structured remediation wrapper tooling can apply without
+// interpreting the message. Actions are remove-file, add-pin, update-module,
+// replace-file and review-file.
+type SuggestedFix struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Finding struct {
+	Rule         string        `json:"rule,omitempty"`
+	Type         string        `json:"type"`
+	Severity     string        `json:"severity"`
+	Package      string        `json:"package,omitempty"`
+	Message      string        `json:"message"`
+	Files        []string      `json:"files,omitempty"`
+	SuggestedFix *SuggestedFix `json:"suggestedFix,omitempty"`
+}
+
+var (
+	findingsMutex sync.Mutex
+	findings      = []Finding{}
+)
+
+func addFinding(finding Finding) {
+	findingsMutex.Lock()
+	defer findingsMutex.Unlock()
+	if finding.Rule == "" {
+		finding.Rule = ruleID(finding.Type)
+	}
+	findings = append(findings, finding)
+}
+
+func resetFindings() {
+	findingsMutex.Lock()
+	defer findingsMutex.Unlock()
+	findings = []Finding{}
+}
+
+func allFindings() []Finding {
+	findingsMutex.Lock()
+	defer findingsMutex.Unlock()
+	return append([]Finding{}, findings...)
+}
+
+// retainFindings drops all findings for which keep returns false.
+func retainFindings(keep func(Finding) bool) {
+	findingsMutex.Lock()
+	defer findingsMutex.Unlock()
+	retained := []Finding{}
+	for _, finding := range findings {
+		if keep(finding) {
+			retained = append(retained, finding)
+		}
+	}
+	findings = retained
+}
+
+func removeFileFix(filePath string) *SuggestedFix {
+	return &SuggestedFix{Action: "remove-file", Target: filePath}
+}
+
+func pinFix(packageName string, version string, detail string) *SuggestedFix {
+	return &SuggestedFix{Action: "add-pin", Target: packageName + "=" + version, Detail: detail}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The fingerprint database maps the SHA-1 checksums of released JARs to
+// their coordinates, to identify JARs without metadata offline. The copy
+// embedded in the binary is refreshed by db update into the cache directory.
+
+//go:embed fingerprints.txt
+var embeddedFingerprints string
+
+//go:embed fingerprint-artifacts.txt
+var fingerprintArtifacts string
+
+const fingerprintsFileName = "fingerprints.txt"
+
+// releasesPerArtifact keeps the database compact, older releases are rarely
+// found in userlib.
+const releasesPerArtifact = 25
+
+var (
+	fingerprintsOnce sync.Once
+	fingerprints     map[string]Coordinates
+)
+
+// parseFingerprints reads lines of a SHA-1 and group:artifact:version.
+func parseFingerprints(text string, into map[string]Coordinates) {
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		parts := strings.Split(fields[1], ":")
+		if len(parts) != 3 {
+			continue
+		}
+		into[strings.ToLower(fields[0])] = Coordinates{group: parts[0], artifact: parts[1], version: parts[2]}
+	}
+}
+
+// fingerprintsFile returns the updated database in the cache directory, or
+// an empty string without one.
+func fingerprintsFile() string {
+	dir := cacheDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, fingerprintsFileName)
+}
+
+// loadFingerprints returns the embedded database merged with the updated
+// one, loaded once per run.
+func loadFingerprints() map[string]Coordinates {
+	fingerprintsOnce.Do(func() {
+		fingerprints = make(map[string]Coordinates)
+		parseFingerprints(embeddedFingerprints, fingerprints)
+		if path := fingerprintsFile(); path != "" {
+			if b, err := ioutil.ReadFile(path); err == nil {
+				parseFingerprints(string(b), fingerprints)
+			} else if !os.IsNotExist(err) {
+				log.Warningf("Unable to read fingerprint database: %v", err)
+			}
+		}
+		log.Debugf("Loaded %d fingerprints", len(fingerprints))
+	})
+	return fingerprints
+}
+
+// identifyByFingerprint identifies a JAR by its checksum in the fingerprint
+// database.
+func identifyByFingerprint(filePath string, logger jarLog) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	known := loadFingerprints()
+	if len(known) == 0 {
+		return jarProp
+	}
+	checksum, err := fileSHA1(filePath)
+	if err != nil {
+		logger.Warningf("Unable to hash %v: %v", filePath, err)
+		return jarProp
+	}
+	if coordinates, ok := known[checksum]; ok {
+		jarProp.Coordinates = coordinates
+	}
+	return jarProp
+}
+
+func formatFingerprints(known map[string]Coordinates) string {
+	lines := []string{}
+	for checksum, c := range known {
+		lines = append(lines, fmt.Sprintf("%v %v:%v:%v", checksum, c.group, c.artifact, c.version))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return strings.SplitN(lines[i], " ", 2)[1] < strings.SplitN(lines[j], " ", 2)[1]
+	})
+	return "# Fingerprints of released JARs: SHA-1 and group:artifact:version.\n" +
+		"# Generated by mendix-userlib-cleaner db update, do not edit.\n" +
+		strings.Join(lines, "\n") + "\n"
+}
+
+// updateFingerprints implements db update. It fetches the checksums of the
+// recent releases of common artifacts from the Maven repository and writes
+// them, with the fingerprints known already, to path or the cache directory.
+// Releases whose checksums are known are not fetched again.
+func updateFingerprints(path string) {
+	if path == "" {
+		path = fingerprintsFile()
+	}
+	if path == "" {
+		fatal("No cache directory for the fingerprint database, use --write to set its path")
+	}
+	known := make(map[string]Coordinates)
+	for checksum, c := range loadFingerprints() {
+		known[checksum] = c
+	}
+	present := make(map[Coordinates]bool)
+	for _, c := range known {
+		present[c] = true
+	}
+
+	r := newRepository()
+	log.Infof("Updating fingerprints from %v", r.baseURL)
+	added := 0
+	for _, line := range strings.Split(fingerprintArtifacts, "\n") {
+		line = strings.TrimSpace(line)
+		parts := strings.Split(line, ":")
+		if strings.HasPrefix(line, "#") || len(parts) != 2 {
+			continue
+		}
+		artifact := Coordinates{group: parts[0], artifact: parts[1]}
+		versions, err := r.releases(artifact)
+		if errors.Is(err, errArtifactNotFound) {
+			log.Warningf("%v is not in the repository", line)
+			continue
+		} else if err != nil {
+			fatalf("Unable to update fingerprints: %v", err)
+		}
+		for _, version := range versions {
+			c := Coordinates{group: artifact.group, artifact: artifact.artifact, version: version}
+			if present[c] {
+				continue
+			}
+			checksum, err := r.sha1(c)
+			if errors.Is(err, errArtifactNotFound) {
+				log.Debugf("No JAR for %v:%v", line, version)
+				continue
+			} else if err != nil {
+				fatalf("Unable to update fingerprints: %v", err)
+			}
+			known[checksum] = c
+			added++
+		}
+		log.Debugf("Fetched fingerprints of %v", line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(formatFingerprints(known)), 0644); err != nil {
+		fatalf("Unable to write fingerprint database: %v", err)
+	}
+	log.Infof("Added %d fingerprint(s), %d in %v", added, len(known), path)
+}
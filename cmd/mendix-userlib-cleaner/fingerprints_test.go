@@ -0,0 +1,30 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fingerprintLine = regexp.MustCompile(`^[0-9a-f]{40} [^:\s]+:[^:\s]+:[^:\s]+$`)
+
+func TestEmbeddedFingerprints(t *testing.T) {
+	entries := 0
+	for _, line := range strings.Split(embeddedFingerprints, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !fingerprintLine.MatchString(line) {
+			t.Errorf("invalid line in fingerprints.txt: %q", line)
+		}
+		entries++
+	}
+	known := make(map[string]Coordinates)
+	parseFingerprints(embeddedFingerprints, known)
+	if len(known) != entries {
+		t.Errorf("parsed %d of %d fingerprints, checksums are duplicated", len(known), entries)
+	}
+	if entries == 0 {
+		t.Skip("the embedded fingerprint database is empty, run make fingerprints")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type fixtureLibrary struct {
+	packageName string
+	name        string
+	vendor      string
+	license     string
+}
+
+var fixtureLibraries = []fixtureLibrary{
+	{"org.apache.commons.lang3", "Apache Commons Lang", "The Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.apache.commons.io", "Apache Commons IO", "The Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.apache.httpcomponents.httpclient", "Apache HttpClient", "The Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.apache.httpcomponents.httpcore", "Apache HttpCore", "The Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.apache.velocity", "Apache Velocity", "Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"com.fasterxml.jackson.core.jackson-databind", "jackson-databind", "FasterXML", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"com.fasterxml.jackson.core.jackson-core", "Jackson-core", "FasterXML", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"com.google.gson", "Gson", "Google Gson Project", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"com.google.guava", "Guava: Google Core Libraries for Java", "Google Inc.", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.slf4j.api", "SLF4J API Module", "SLF4J", "http://www.opensource.org/licenses/mit-license.php"},
+	{"org.bouncycastle.bcprov", "Bouncy Castle Provider", "BouncyCastle.org", "https://www.bouncycastle.org/licence.html"},
+	{"org.json", "JSON in Java", "JSON.org", "http://json.org/license.html"},
+	{"com.sun.mail.javax.mail", "JavaMail API", "Oracle", "https://javaee.github.io/javamail/LICENSE"},
+	{"org.postgresql.jdbc", "PostgreSQL JDBC Driver", "PostgreSQL Global Development Group", "https://jdbc.postgresql.org/about/license.html"},
+	{"org.apache.poi", "Apache POI", "The Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+	{"org.apache.xmlbeans", "XmlBeans", "Apache Software Foundation", "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+}
+
+// generateFixture writes a synthetic userlib into targetDir. The JARs carry
+// metadata in the same shapes the parsers understand: OSGi manifests,
+// pom.properties, or nothing but a versioned file name and classes.
+func generateFixture(targetDir string, packages int, duplicateRatio float64, broken int, shaded int, seed int64) {
+	if files, err := ioutil.ReadDir(targetDir); err == nil && len(files) > 0 {
+		fatalf("Refusing to generate fixture in non-empty directory: %v", targetDir)
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		fatal(err)
+	}
+	log.Infof("Generating fixture in %v", targetDir)
+	random := rand.New(rand.NewSource(seed))
+
+	libraries := []fixtureLibrary{}
+	jarCount := 0
+	duplicateCount := 0
+	for i := 0; i < packages; i++ {
+		library := fixtureLibrary{fmt.Sprintf("com.example.lib%d", i), fmt.Sprintf("Example Library %d", i), "Example Corp", "Proprietary"}
+		if i < len(fixtureLibraries) {
+			library = fixtureLibraries[i]
+		}
+		libraries = append(libraries, library)
+
+		style := random.Intn(3)
+		major, minor, patch := 1+random.Intn(5), random.Intn(15), 1+random.Intn(20)
+		versions := []string{fmt.Sprintf("%d.%d.%d", major, minor, patch)}
+		if random.Float64() < duplicateRatio {
+			versions = append(versions, fmt.Sprintf("%d.%d.%d", major, minor, random.Intn(patch)))
+			if random.Intn(3) == 0 && minor > 0 {
+				versions = append(versions, fmt.Sprintf("%d.%d.%d", major, minor-1, random.Intn(20)))
+			}
+		}
+		for _, version := range versions {
+			jar := JarProperties{Coordinates: Coordinates{version: version}, name: library.name, vendor: library.vendor, license: library.license}
+			jar.setName(library.packageName)
+			writeFixtureJar(targetDir, jar, style)
+			jarCount++
+		}
+		duplicateCount += len(versions) - 1
+	}
+
+	for i := 0; i < shaded && len(libraries) > 0; i++ {
+		writeShadedFixtureJar(targetDir, fmt.Sprintf("uber-app-%d.0-all.jar", i+1), libraries, random)
+		jarCount++
+	}
+
+	for i := 0; i < broken; i++ {
+		garbage := make([]byte, 512+random.Intn(4096))
+		random.Read(garbage)
+		fileName := filepath.Join(targetDir, fmt.Sprintf("broken-%d.0.jar", i+1))
+		if err := ioutil.WriteFile(fileName, garbage, 0644); err != nil {
+			fatal(err)
+		}
+		jarCount++
+	}
+
+	log.Infof("Generated %d jars: %d packages, %d duplicates, %d shaded, %d broken", jarCount, packages, duplicateCount, shaded, broken)
+}
+
+func fixtureCoordinates(packageName string) (string, string) {
+	i := strings.LastIndex(packageName, ".")
+	if i < 0 {
+		return packageName, packageName
+	}
+	return packageName[:i], packageName[i+1:]
+}
+
+func writeFixtureJar(targetDir string, jar JarProperties, style int) {
+	groupId, artifactId := fixtureCoordinates(jar.key())
+	if style == 2 && !(strings.HasPrefix(jar.key(), "org.") || strings.HasPrefix(jar.key(), "com.")) {
+		// optimistic parsing only recognizes org and com packages
+		style = 0
+	}
+
+	entries := make(map[string]string)
+	switch style {
+	case 0:
+		// jar format 2: OSGi manifest
+		entries["META-INF/MANIFEST.MF"] = fmt.Sprintf("Manifest-Version: 1.0\r\nBundle-ManifestVersion: 2\r\nBundle-SymbolicName: %s\r\nBundle-Version: %s\r\nBundle-Name: %s\r\nBundle-Vendor: %s\r\nBundle-License: %s\r\n\r\n",
+			jar.key(), jar.version, jar.name, jar.vendor, jar.license)
+	case 1:
+		// jar format 1: pom.properties
+		entries["META-INF/MANIFEST.MF"] = "Manifest-Version: 1.0\r\nCreated-By: Apache Maven\r\n\r\n"
+		entries[fmt.Sprintf("META-INF/maven/%s/%s/pom.properties", groupId, artifactId)] = fmt.Sprintf("#Created by Apache Maven\ngroupId=%s\nartifactId=%s\nversion=%s\n", groupId, artifactId, jar.version)
+	}
+	for _, className := range []string{"Main", "Util", "internal/Helper"} {
+		entries[strings.Replace(jar.key(), ".", "/", -1)+"/"+className+".class"] = fixtureClassFile
+	}
+
+	writeZip(filepath.Join(targetDir, fmt.Sprintf("%s-%s.jar", artifactId, jar.version)), entries)
+}
+
+func writeShadedFixtureJar(targetDir string, fileName string, libraries []fixtureLibrary, random *rand.Rand) {
+	entries := map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\nCreated-By: Apache Maven Shade Plugin\r\n\r\n",
+	}
+	for i := 0; i < 3; i++ {
+		library := libraries[random.Intn(len(libraries))]
+		groupId, artifactId := fixtureCoordinates(library.packageName)
+		entries[fmt.Sprintf("META-INF/maven/%s/%s/pom.properties", groupId, artifactId)] = fmt.Sprintf("groupId=%s\nartifactId=%s\nversion=%d.0.0\n", groupId, artifactId, 1+random.Intn(5))
+		entries[strings.Replace(library.packageName, ".", "/", -1)+"/Main.class"] = fixtureClassFile
+	}
+	writeZip(filepath.Join(targetDir, fileName), entries)
+}
+
+// minimal class file header: magic number and Java 8 class file version
+var fixtureClassFile = "\xca\xfe\xba\xbe\x00\x00\x00\x34"
+
+func writeZip(filePath string, entries map[string]string) {
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	// the manifest is expected to be the first entry
+	sortManifestFirst(names)
+
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	for _, name := range names {
+		w, err := writer.Create(name)
+		if err != nil {
+			fatal(err)
+		}
+		if _, err := w.Write([]byte(entries[name])); err != nil {
+			fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(filePath, buffer.Bytes(), 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func sortManifestFirst(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "META-INF/MANIFEST.MF" || names[j] == "META-INF/MANIFEST.MF" {
+			return names[i] == "META-INF/MANIFEST.MF"
+		}
+		return names[i] < names[j]
+	})
+}
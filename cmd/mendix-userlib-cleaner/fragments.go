@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseFragmentHost reads an OSGi Fragment-Host header such as
+// org.example.host;bundle-version="[1.0,2.0)".
+func parseFragmentHost(jarProp *JarProperties, header string) {
+	if header == "" {
+		return
+	}
+	parts := strings.Split(header, ";")
+	jarProp.fragmentHost = strings.TrimSpace(parts[0])
+	for _, part := range parts[1:] {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) == 2 && strings.TrimSpace(pair[0]) == "bundle-version" {
+			jarProp.fragmentHostVersion = osgiVersionRange(strings.Trim(strings.TrimSpace(pair[1]), `"`))
+		}
+	}
+}
+
+// osgiVersionRange converts OSGi interval notation like [1.0,2.0) to the
+// constraints understood by parseVersionRange. A bare version is a minimum.
+// Malformed ranges return an empty string, like no range.
+func osgiVersionRange(text string) string {
+	if text == "" {
+		return ""
+	}
+	if !strings.HasPrefix(text, "[") && !strings.HasPrefix(text, "(") {
+		return ">=" + text
+	}
+	if len(text) < 2 || (!strings.HasSuffix(text, "]") && !strings.HasSuffix(text, ")")) {
+		return ""
+	}
+	bounds := strings.SplitN(text[1:len(text)-1], ",", 2)
+	if len(bounds) != 2 || strings.TrimSpace(bounds[0]) == "" || strings.TrimSpace(bounds[1]) == "" {
+		return ""
+	}
+	lower := ">"
+	if strings.HasPrefix(text, "[") {
+		lower = ">="
+	}
+	upper := "<"
+	if strings.HasSuffix(text, "]") {
+		upper = "<="
+	}
+	return lower + strings.TrimSpace(bounds[0]) + " " + upper + strings.TrimSpace(bounds[1])
+}
+
+// applyFragmentHosts makes sure no fragment bundle is kept after its host was
+// dropped and reports every fragment/host pair.
+func applyFragmentHosts(jars []JarProperties, keepJars map[string]JarProperties) {
+	for _, fragment := range jars {
+		if fragment.fragmentHost == "" || keepJars[fragment.key()].filePath != fragment.filePath {
+			continue
+		}
+
+		hostInUserlib := false
+		for _, jar := range jars {
+			if jar.key() == normalizeKey(fragment.fragmentHost) {
+				hostInUserlib = true
+			}
+		}
+		if !hostInUserlib {
+			log.Infof("Fragment %v attaches to host %v which is not in userlib", fragment.fileName, fragment.fragmentHost)
+			continue
+		}
+
+		host, ok := keepJars[normalizeKey(fragment.fragmentHost)]
+		if !ok {
+			log.Warningf("Host %v of fragment %v is removed, removing the fragment as well", fragment.fragmentHost, fragment.fileName)
+			addFinding(Finding{Type: "fragment-without-host", Severity: severityWarning, Package: fragment.key(),
+				Message: fmt.Sprintf("Host %v of fragment %v is removed", fragment.fragmentHost, fragment.fileName),
+				Files:   []string{fragment.filePath}, SuggestedFix: removeFileFix(fragment.filePath)})
+			delete(keepJars, fragment.key())
+			continue
+		}
+		log.Infof("Fragment %v attaches to host %v", fragment.fileName, host.fileName)
+		if fragment.fragmentHostVersion == "" {
+			continue
+		}
+		vr, err := parseVersionRange(fragment.fragmentHostVersion)
+		if err == nil && !vr.contains(host.version) {
+			log.Warningf("Fragment %v requires %v %v but %v is kept", fragment.fileName, fragment.fragmentHost, vr, host.version)
+			addFinding(Finding{Type: "fragment-host-version", Severity: severityWarning, Package: fragment.key(),
+				Message:      fmt.Sprintf("Fragment %v requires %v %v but %v is kept", fragment.fileName, fragment.fragmentHost, vr, host.version),
+				Files:        []string{fragment.filePath, host.filePath},
+				SuggestedFix: &SuggestedFix{Action: "update-module", Target: fragment.key(), Detail: "use a fragment built for " + fragment.fragmentHost + " " + host.version}})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestOsgiVersionRange(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"1.0", ">=1.0"},
+		{"[1.0,2.0)", ">=1.0 <2.0"},
+		{"(1.0, 2.0]", ">1.0 <=2.0"},
+		{"", ""},
+		{"[", ""},
+		{"(", ""},
+		{"[]", ""},
+		{"[1.0", ""},
+		{"[1.0)", ""},
+		{"[,2.0)", ""},
+	}
+	for _, test := range tests {
+		if got := osgiVersionRange(test.text); got != test.want {
+			t.Errorf("osgiVersionRange(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseFragmentHostMalformedVersion(t *testing.T) {
+	for _, header := range []string{`org.example.host;bundle-version="["`, `org.example.host;bundle-version="("`, `org.example.host;bundle-version=""`} {
+		jar := JarProperties{}
+		parseFragmentHost(&jar, header)
+		if jar.fragmentHost != "org.example.host" || jar.fragmentHostVersion != "" {
+			t.Errorf("%v: got host %q, version %q", header, jar.fragmentHost, jar.fragmentHostVersion)
+		}
+	}
+}
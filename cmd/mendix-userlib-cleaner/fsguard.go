@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// deletableSuffixes is the complete list of files the tool may ever delete or
+// rename: JARs and the metadata files Mendix keeps next to them, such as
+// checker-qual-2.5.2.jar.meta and commons-io-2.6.jar.CommunityCommons.RequiredLib.
+// Archives analyzed like JARs with --extensions and verified copies of JARs
+// are the only exceptions.
+var deletableSuffixes = []string{".jar", ".jar.meta", ".RequiredLib"}
+
+var mutableRoots = []string{}
+
+// guardedTarget is the target whose files may be changed, set by guardTarget.
+var guardedTarget string
+
+// verifiedCopies are files with other names, like foo.jar.bak, that have the
+// same content as a JAR, by their SHA-256. They may be deleted as long as
+// their content is unchanged.
+var verifiedCopies = make(map[string]string)
+
+func allowVerifiedCopy(filePath string, hash string) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		fatal(err)
+	}
+	verifiedCopies[abs] = hash
+}
+
+func isVerifiedCopy(filePath string) bool {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	hash, ok := verifiedCopies[abs]
+	if !ok {
+		return false
+	}
+	actual, err := fileSHA256(filePath)
+	return err == nil && actual == hash
+}
+
+// allowMutationsIn registers a directory in which files may be changed.
+func allowMutationsIn(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fatal(err)
+	}
+	mutableRoots = append(mutableRoots, abs)
+}
+
+// guardTarget limits the files that may be changed to those in targetDir and
+// the --quarantine, for a run or request on targetDir.
+func guardTarget(targetDir string) {
+	mutableRoots = []string{}
+	guardedTarget = targetDir
+	allowMutationsIn(targetDir)
+	if quarantine := viper.GetString("quarantine"); quarantine != "" {
+		allowMutationsIn(quarantine)
+	}
+}
+
+// checkMutation validates a path before it is deleted or renamed. Every
+// file system mutation has to pass this check, regardless of the feature
+// asking for it.
+func checkMutation(filePath string) error {
+	if err := checkSuffix(filePath); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(filePath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%v is a directory", filePath)
+	}
+	return checkMutableRoot(filePath)
+}
+
+func checkSuffix(filePath string) error {
+	base := filepath.Base(filePath)
+	for _, suffix := range deletableSuffixes {
+		if strings.HasSuffix(base, suffix) && strings.Contains(base, ".jar") {
+			return nil
+		}
+	}
+	if isArchive(base) || isVerifiedCopy(filePath) {
+		return nil
+	}
+	return fmt.Errorf("%v does not have an allowed extension (%v)", base, strings.Join(deletableSuffixes, ", "))
+}
+
+func checkMutableRoot(filePath string) error {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	for _, root := range mutableRoots {
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is outside of the target directory", filePath)
+}
+
+func removeFile(filePath string) error {
+	if err := checkMutation(filePath); err != nil {
+		return err
+	}
+	return os.Remove(filePath)
+}
+
+// renameFile moves a file to a new path that must not exist yet. Both paths
+// are validated, and moves across file systems fall back to copying.
+func renameFile(filePath string, newPath string) error {
+	if err := checkMutation(filePath); err != nil {
+		return err
+	}
+	// keeping the name of a verified copy is fine, it was checked above
+	if err := checkSuffix(newPath); err != nil && filepath.Base(newPath) != filepath.Base(filePath) {
+		return err
+	}
+	if err := checkMutableRoot(newPath); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return fmt.Errorf("%v already exists", newPath)
+	}
+	if err := os.Rename(filePath, newPath); err == nil {
+		return nil
+	}
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(newPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Remove(filePath)
+}
+
+// removeEmptyDir removes a directory inside a mutable root if it is empty.
+func removeEmptyDir(dir string) error {
+	if err := checkMutableRoot(dir); err != nil {
+		return err
+	}
+	return os.Remove(dir)
+}
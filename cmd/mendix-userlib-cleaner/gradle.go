@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// gradleModule is the part of Gradle module metadata, the .module file
+// published next to or embedded in an artifact, that identifies it.
+type gradleModule struct {
+	Component struct {
+		Group   string `json:"group"`
+		Module  string `json:"module"`
+		Version string `json:"version"`
+	} `json:"component"`
+	Variants []struct {
+		Name       string            `json:"name"`
+		Attributes map[string]string `json:"attributes"`
+		Files      []struct {
+			Name string `json:"name"`
+		} `json:"files"`
+	} `json:"variants"`
+}
+
+func isGradleModule(name string) bool {
+	return strings.HasPrefix(name, "META-INF/") && strings.HasSuffix(name, ".module")
+}
+
+// parseGradleModule reads coordinates from Gradle module metadata. The usage
+// of the variants listing the JAR's file name tells whether it is the API, the
+// runtime or another variant, e.g. java-api or java-runtime.
+func parseGradleModule(filePath string, text string) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	module := gradleModule{}
+	if err := json.Unmarshal([]byte(text), &module); err != nil {
+		log.Debugf("Ignoring invalid Gradle module metadata in %v: %v", filePath, err)
+		return jarProp
+	}
+	if module.Component.Group == "" || module.Component.Module == "" {
+		return jarProp
+	}
+	jarProp.group = module.Component.Group
+	jarProp.artifact = module.Component.Module
+	jarProp.version = module.Component.Version
+
+	for _, variant := range module.Variants {
+		for _, file := range variant.Files {
+			if file.Name != jarProp.fileName {
+				continue
+			}
+			usage := variant.Attributes["org.gradle.usage"]
+			if usage == "" {
+				usage = variant.Name
+			}
+			if !contains(jarProp.variants, usage) {
+				jarProp.variants = append(jarProp.variants, usage)
+			}
+		}
+	}
+	return jarProp
+}
+
+// checkVariants warns about duplicates published as different Gradle
+// variants, e.g. an API JAR kept in place of the runtime JAR, since the kept
+// one may lack the implementation.
+func checkVariants(jars []JarProperties, keepJars map[string]JarProperties) {
+	for _, jar := range jars {
+		keepJar, ok := keepJars[jar.key()]
+		if !ok || keepJar.filePath == jar.filePath || len(jar.variants) == 0 || len(keepJar.variants) == 0 {
+			continue
+		}
+		if strings.Join(jar.variants, ", ") == strings.Join(keepJar.variants, ", ") {
+			continue
+		}
+		log.Warningf("Duplicates of %v are different variants: %v (%v) vs. kept %v (%v)", jar.key(), jar.fileName, strings.Join(jar.variants, ", "), keepJar.fileName, strings.Join(keepJar.variants, ", "))
+		addFinding(Finding{Type: "variant-mismatch", Severity: severityWarning, Package: jar.key(),
+			Message:      fmt.Sprintf("%v (%v) is a different variant than kept %v (%v)", jar.fileName, strings.Join(jar.variants, ", "), keepJar.fileName, strings.Join(keepJar.variants, ", ")),
+			Files:        []string{jar.filePath, keepJar.filePath},
+			SuggestedFix: pinFix(jar.key(), jar.version, "keep the runtime variant")})
+	}
+}
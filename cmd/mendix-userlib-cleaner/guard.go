@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	guardMutex sync.Mutex
+	guarded    = make(map[string]string)
+)
+
+// guardFile keeps a file that would be removed, for the given reason.
+func guardFile(filePath string, reason string) {
+	guardMutex.Lock()
+	defer guardMutex.Unlock()
+	guarded[filePath] = reason
+}
+
+// guardReason tells why a file is kept although it is not the JAR to keep.
+func guardReason(filePath string) (string, bool) {
+	guardMutex.Lock()
+	defer guardMutex.Unlock()
+	reason, ok := guarded[filePath]
+	return reason, ok
+}
+
+func resetGuards() {
+	guardMutex.Lock()
+	defer guardMutex.Unlock()
+	guarded = make(map[string]string)
+}
+
+// javaPackage returns the package of a fully qualified class name.
+func javaPackage(className string) string {
+	if i := strings.LastIndex(className, "."); i >= 0 {
+		return className[:i]
+	}
+	return ""
+}
+
+// parseImportPackage returns the packages of an OSGi Import-Package header
+// that are not optional, e.g. org.slf4j from
+// org.slf4j;version="[1.7,2)",javax.annotation;resolution:=optional.
+func parseImportPackage(value string) []string {
+	clauses := []string{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				clauses = append(clauses, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	clauses = append(clauses, value[start:])
+
+	packages := []string{}
+	for _, clause := range clauses {
+		parts := strings.Split(clause, ";")
+		optional := false
+		for _, directive := range parts[1:] {
+			if strings.Replace(strings.TrimSpace(directive), " ", "", -1) == "resolution:=optional" {
+				optional = true
+			}
+		}
+		name := strings.TrimSpace(parts[0])
+		if optional || name == "" || strings.HasPrefix(name, "java.") {
+			continue
+		}
+		packages = append(packages, name)
+	}
+	return packages
+}
+
+// jarDependencies returns the packages a JAR imports according to its
+// manifest and the group:artifact of the dependencies in its pom.xml that are
+// neither optional, test nor provided scope.
+func jarDependencies(filePath string) ([]string, []string) {
+	archive, err := openJar(filePath)
+	if err != nil {
+		return nil, nil
+	}
+	defer archive.Close()
+	packages, artifacts := []string{}, []string{}
+	for _, f := range archive.File {
+		if f.Name == "META-INF/MANIFEST.MF" {
+			packages = append(packages, parseImportPackage(manifestValue(string(extractEntry(f)), "Import-Package"))...)
+		} else if isPOMXML(f.Name) {
+			project := struct {
+				Dependencies []struct {
+					GroupID    string `xml:"groupId"`
+					ArtifactID string `xml:"artifactId"`
+					Scope      string `xml:"scope"`
+					Optional   string `xml:"optional"`
+				} `xml:"dependencies>dependency"`
+			}{}
+			if err := xml.Unmarshal(extractEntry(f), &project); err != nil {
+				continue
+			}
+			for _, dependency := range project.Dependencies {
+				scope := strings.TrimSpace(dependency.Scope)
+				if strings.TrimSpace(dependency.Optional) == "true" || scope == "test" || scope == "provided" || scope == "system" {
+					continue
+				}
+				artifacts = append(artifacts, strings.TrimSpace(dependency.GroupID)+":"+strings.TrimSpace(dependency.ArtifactID))
+			}
+		}
+	}
+	return packages, artifacts
+}
+
+// guardSoleProviders keeps removed JARs that are the only ones providing a
+// package that a kept JAR depends on, by its Import-Package or its pom.xml
+// dependencies. Such a removal would cause NoClassDefFoundError, e.g. when
+// the kept version of a library no longer contains a package, so it is
+// reported as a warning instead of carried out.
+func guardSoleProviders(jars []JarProperties, keepJars map[string]JarProperties) {
+	log.Info("Checking for removals of the only provider of a package")
+	provided := make(map[string]bool)
+	removed := []JarProperties{}
+	for _, jar := range jars {
+		if keepJars[jar.key()].filePath != jar.filePath {
+			removed = append(removed, jar)
+			continue
+		}
+		for _, className := range listClasses(jar.filePath) {
+			provided[javaPackage(className)] = true
+		}
+	}
+
+	// packages only provided by removed JARs
+	orphans := make(map[string][]JarProperties)
+	for _, jar := range removed {
+		for _, className := range listClasses(jar.filePath) {
+			if name := javaPackage(className); !provided[name] {
+				orphans[name] = append(orphans[name], jar)
+			}
+		}
+	}
+	if len(orphans) == 0 {
+		return
+	}
+
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		dependent := keepJars[packageName]
+		imports, artifacts := jarDependencies(dependent.filePath)
+		needed := make(map[string][]string)
+		for _, name := range imports {
+			for _, jar := range orphans[name] {
+				if !contains(needed[jar.filePath], name) {
+					needed[jar.filePath] = append(needed[jar.filePath], name)
+				}
+			}
+		}
+		for _, jar := range removed {
+			if !contains(artifacts, jar.group+":"+jar.artifact) {
+				continue
+			}
+			for name, providers := range orphans {
+				for _, provider := range providers {
+					if provider.filePath == jar.filePath && !contains(needed[jar.filePath], name) {
+						needed[jar.filePath] = append(needed[jar.filePath], name)
+					}
+				}
+			}
+		}
+
+		for _, jar := range removed {
+			names := needed[jar.filePath]
+			if len(names) == 0 {
+				continue
+			}
+			sort.Strings(names)
+			if _, ok := guardReason(jar.filePath); ok {
+				continue
+			}
+			reason := fmt.Sprintf("only provider of %v required by kept %v", strings.Join(names, ", "), dependent.fileName)
+			guardFile(jar.filePath, reason)
+			log.Warningf("Keeping %v, the %v", jar.fileName, reason)
+			addFinding(Finding{Type: "sole-provider", Severity: severityWarning, Package: jar.key(),
+				Message: fmt.Sprintf("%v is not removed, it is the %v", jar.fileName, reason),
+				Files:   []string{jar.filePath, dependent.filePath},
+				SuggestedFix: &SuggestedFix{Action: "review-file", Target: jar.filePath,
+					Detail: "update " + dependent.fileName + " or keep a version of " + jar.key() + " providing " + strings.Join(names, ", ")}})
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// staleAfter is how long a package has to stay unchanged to be pointed out
+// as possibly abandoned.
+const staleAfter = 365 * 24 * time.Hour
+
+// History remembers the packages seen in a userlib across runs.
+type History struct {
+	Packages map[string]PackageHistory `json:"packages"`
+}
+
+type PackageHistory struct {
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastChanged time.Time `json:"lastChanged"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Versions    []string  `json:"versions"`
+}
+
+func loadHistory(path string) History {
+	history := History{Packages: make(map[string]PackageHistory)}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history
+	}
+	if err != nil {
+		fatalf("Unable to read history: %v", err)
+	}
+	if err := json.Unmarshal(b, &history); err != nil {
+		fatalf("Unable to parse history %v: %v", path, err)
+	}
+	if history.Packages == nil {
+		history.Packages = make(map[string]PackageHistory)
+	}
+	return history
+}
+
+// recordHistory updates the history at path with the packages of this run
+// and fills in when each of the JARs' packages first appeared and last
+// changed. Packages seen for the first time are dated by the modification
+// time of their files, so the first run already gives an estimate.
+func recordHistory(path string, jars []JarProperties) {
+	history := loadHistory(path)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	versions := make(map[string][]string)
+	oldest := make(map[string]time.Time)
+	newest := make(map[string]time.Time)
+	for _, jar := range jars {
+		packageName := jar.key()
+		if !contains(versions[packageName], jar.version) {
+			versions[packageName] = append(versions[packageName], jar.version)
+		}
+		if info, err := os.Stat(jar.filePath); err == nil {
+			modified := info.ModTime().UTC().Truncate(time.Second)
+			if oldest[packageName].IsZero() || modified.Before(oldest[packageName]) {
+				oldest[packageName] = modified
+			}
+			if modified.After(newest[packageName]) {
+				newest[packageName] = modified
+			}
+		}
+	}
+
+	for packageName, packageVersions := range versions {
+		sort.Strings(packageVersions)
+		entry, ok := history.Packages[packageName]
+		switch {
+		case !ok:
+			entry = PackageHistory{FirstSeen: oldest[packageName], LastChanged: newest[packageName]}
+			if entry.FirstSeen.IsZero() {
+				entry.FirstSeen, entry.LastChanged = now, now
+			}
+		case strings.Join(entry.Versions, " ") != strings.Join(packageVersions, " ") || newest[packageName].After(entry.LastChanged):
+			entry.LastChanged = now
+		}
+		entry.Versions = packageVersions
+		entry.LastSeen = now
+		history.Packages[packageName] = entry
+	}
+
+	b, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(path, b, 0644); err != nil {
+		fatalf("Unable to write history: %v", err)
+	}
+
+	for i := range jars {
+		entry := history.Packages[jars[i].key()]
+		jars[i].firstSeen, jars[i].lastChanged = entry.FirstSeen, entry.LastChanged
+	}
+
+	stale := []string{}
+	for packageName := range versions {
+		if now.Sub(history.Packages[packageName].LastChanged) > staleAfter {
+			stale = append(stale, packageName)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return history.Packages[stale[i]].LastChanged.Before(history.Packages[stale[j]].LastChanged)
+	})
+	for _, packageName := range stale {
+		entry := history.Packages[packageName]
+		log.Infof("Unchanged since %v: %v (first seen %v)", entry.LastChanged.Format("2006-01-02"), packageName, entry.FirstSeen.Format("2006-01-02"))
+	}
+	log.Debugf("Recorded %d package(s) in %v", len(versions), path)
+}
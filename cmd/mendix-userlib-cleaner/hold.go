@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const holdLayout = "2006-01-02"
+
+// parseHolds reads the --hold values, package=date, during which the older
+// duplicates of a package are reported but not removed, e.g. to test a new
+// library version next to the old one.
+func parseHolds(values []string) map[string]time.Time {
+	holds := make(map[string]time.Time)
+	for _, value := range values {
+		pair := strings.SplitN(value, "=", 2)
+		if len(pair) < 2 || pair[0] == "" {
+			fatalf("Invalid --hold value %q, expected package=YYYY-MM-DD", value)
+		}
+		until, err := time.ParseInLocation(holdLayout, pair[1], time.Local)
+		if err != nil {
+			fatalf("Invalid --hold date in %q, expected YYYY-MM-DD", value)
+		}
+		holds[normalizeKey(pair[0])] = until
+	}
+	return holds
+}
+
+// isHeld tells whether the duplicates of a package are held at now, without
+// logging ended holds.
+func isHeld(packageName string, now time.Time) bool {
+	until, ok := parseHolds(viper.GetStringSlice("hold"))[packageName]
+	return ok && now.Before(until.AddDate(0, 0, 1))
+}
+
+// activeHolds returns the last day the duplicates of each held package are
+// kept, for holds that have not ended. Ended holds are logged, so they can be
+// removed from the config.
+func activeHolds(now time.Time) map[string]string {
+	active := make(map[string]string)
+	for key, until := range parseHolds(viper.GetStringSlice("hold")) {
+		if now.Before(until.AddDate(0, 0, 1)) {
+			active[key] = until.Format(holdLayout)
+		} else {
+			log.Infof("Hold on %v ended on %v, its duplicates are removed as usual", key, until.Format(holdLayout))
+		}
+	}
+	return active
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ignoreFileName is the file in the target listing the files that are never
+// analyzed or removed, with the patterns of .gitignore: lines starting with #
+// are comments, ! negates a pattern, a leading / anchors it to the directory
+// of the file, a trailing / matches directories only and ** matches any
+// number of directories. The last matching pattern wins.
+const ignoreFileName = ".cleanerignore"
+
+// ignoreRule is a pattern of an ignore file, compiled to a regular
+// expression matching paths relative to the directory of the file.
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func loadIgnoreRules(dir string) []ignoreRule {
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warningf("Unable to read %v: %v", ignoreFileName, err)
+			degradeCheck("ignore file", err.Error())
+		}
+		return nil
+	}
+	defer file.Close()
+
+	rules := []ignoreRule{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseIgnoreRule(line)
+		if err != nil {
+			log.Warningf("Ignoring invalid pattern in %v: %v", ignoreFileName, line)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseIgnoreRule compiles a line of an ignore file. A pattern without a
+// slash, other than a trailing one, matches a name at any depth.
+func parseIgnoreRule(line string) (ignoreRule, error) {
+	rule := ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				return rule, fmt.Errorf("unterminated [ in %v", line)
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			b.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return rule, err
+	}
+	rule.pattern = pattern
+	return rule, nil
+}
+
+// ignoreScope holds the rules of the .cleanerignore in dir, which apply to
+// dir and its subdirectories.
+type ignoreScope struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// isIgnoredIn tells whether a file or directory is ignored by the
+// .cleanerignore files in its directory and above, matching its path relative
+// to each of them. Rules of deeper files override those above, as with
+// .gitignore.
+func isIgnoredIn(scopes []ignoreScope, filePath string, isDir bool) bool {
+	ignored := false
+	for _, scope := range scopes {
+		rel, err := filepath.Rel(scope.dir, filePath)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range scope.rules {
+			if (!rule.dirOnly || isDir) && rule.pattern.MatchString(rel) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+func isIgnored(patterns []string, fileName string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, fileName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFiles applies --include and --exclude to the files of the target,
+// before any of them is parsed. Patterns match the file name or the path
+// relative to the target, like legacy/*.jar. Excluded files are neither
+// analyzed nor removed. With --include, only the JARs matching one of its
+// patterns are analyzed, other files like .meta files are kept for them.
+func filterFiles(targetDir string, filePaths []string) []string {
+	include := viper.GetStringSlice("include")
+	exclude := viper.GetStringSlice("exclude")
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			fatalf("Invalid pattern %q: %v", pattern, err)
+		}
+	}
+	filtered := []string{}
+	for _, filePath := range filePaths {
+		name, rel := filepath.Base(filePath), relativePath(targetDir, filePath)
+		if isIgnored(exclude, name) || isIgnored(exclude, rel) {
+			skipFile(filePath, "matches --exclude")
+			continue
+		}
+		if len(include) > 0 && isArchive(filePath) && !isIgnored(include, name) && !isIgnored(include, rel) {
+			skipFile(filePath, "does not match --include")
+			continue
+		}
+		filtered = append(filtered, filePath)
+	}
+	return filtered
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// imageLibDirs are the folders of the app in a Docker image holding JARs,
+// like /opt/mendix/build/model/lib/userlib. The lib folders of the JDK and
+// the runtime are left out.
+var imageLibDirs = []string{"userlib", "vendorlib"}
+
+// Whiteout files in a layer remove a path, or all of a directory, from the
+// layers below.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// extractedImages are the temporary directories Docker images are extracted
+// to. They are analyzed without changes.
+var extractedImages = make(map[string]bool)
+
+// imageManifest is an image in the manifest.json of docker save.
+type imageManifest struct {
+	RepoTags []string
+	Layers   []string
+}
+
+// imageLayer is what a layer adds to and removes from the layers below.
+type imageLayer struct {
+	files     map[string]string
+	whiteouts []string
+	opaque    []string
+}
+
+// extractImage extracts the JARs in the userlib and vendorlib folders of the
+// app in a docker save archive to a temporary directory, as they are in the
+// image once its layers are applied in order, and returns the directory.
+func extractImage(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		fatalf("Unable to open %v: %v", filePath, err)
+	}
+	defer file.Close()
+	root, err := ioutil.TempDir(tempDir(), "image")
+	if err != nil {
+		fatal(err)
+	}
+
+	// the manifest may come after the layers, so every file that reads as a
+	// tar is kept until it is known which are the layers
+	var manifests []imageManifest
+	layers := make(map[string]imageLayer)
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fatalf("Unable to read %v: %v", filePath, err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if name == "manifest.json" {
+			if err := json.NewDecoder(reader).Decode(&manifests); err != nil {
+				fatalf("Unable to parse the manifest.json of %v: %v", filePath, err)
+			}
+			continue
+		}
+		if layer, ok := readImageLayer(reader, filepath.Join(root, fmt.Sprintf("layer%03d", len(layers)))); ok {
+			layers[name] = layer
+		}
+	}
+	if len(manifests) == 0 {
+		fatalf("%v is no docker save archive, it has no manifest.json", filePath)
+	}
+	image := manifests[0]
+	if len(manifests) > 1 {
+		log.Warningf("%v has %d images, analyzing %v", filePath, len(manifests), strings.Join(image.RepoTags, ", "))
+	}
+
+	merged := make(map[string]string)
+	for _, name := range image.Layers {
+		layer, ok := layers[path.Clean(name)]
+		if !ok {
+			fatalf("Layer %v of %v is missing or no tar archive", name, filePath)
+		}
+		for _, dir := range layer.opaque {
+			removeImagePaths(merged, dir, false)
+		}
+		for _, removed := range layer.whiteouts {
+			removeImagePaths(merged, removed, true)
+		}
+		for name, extracted := range layer.files {
+			merged[name] = extracted
+		}
+	}
+
+	dir := filepath.Join(root, strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+	count := 0
+	for name, extracted := range merged {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			fatal(err)
+		}
+		if err := os.Rename(extracted, target); err != nil {
+			fatal(err)
+		}
+		if isArchive(name) {
+			count++
+		}
+	}
+	if count == 0 {
+		fatalf("%v contains no JARs in a %v folder", filePath, strings.Join(imageLibDirs, ", "))
+	}
+	log.Infof("Analyzing %d JAR(s) in %v", count, filePath)
+	extractedPackages[dir] = filePath
+	extractedImages[dir] = true
+	return dir
+}
+
+// readImageLayer extracts the JARs in the lib folders of a layer, which may
+// be gzipped, to dir. It returns false if r is no tar archive, like the
+// config of the image.
+func readImageLayer(r io.Reader, dir string) (imageLayer, bool) {
+	buffered := bufio.NewReader(r)
+	var reader io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return imageLayer{}, false
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	layer := imageLayer{files: make(map[string]string)}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return layer, true
+		}
+		if err != nil {
+			return imageLayer{}, false
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		parent, base := path.Split(name)
+		if base == whiteoutOpaque {
+			layer.opaque = append(layer.opaque, path.Clean(parent))
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			layer.whiteouts = append(layer.whiteouts, path.Join(parent, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		}
+		if !header.FileInfo().Mode().IsRegular() || !inLibDir(name, imageLibDirs) {
+			continue
+		}
+		if !isArchive(name) && !strings.HasSuffix(name, ".meta") && !strings.HasSuffix(name, ".RequiredLib") {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			fatal(err)
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			fatal(err)
+		}
+		_, err = io.Copy(out, archive)
+		out.Close()
+		if err != nil {
+			return imageLayer{}, false
+		}
+		layer.files[name] = target
+	}
+}
+
+// removeImagePaths removes what a whiteout hides from the merged layers: the
+// path itself and everything below it, or only what is below it for an
+// opaque directory.
+func removeImagePaths(merged map[string]string, removed string, self bool) {
+	for name := range merged {
+		if removed == "." || (self && name == removed) || strings.HasPrefix(name, removed+"/") {
+			delete(merged, name)
+		}
+	}
+}
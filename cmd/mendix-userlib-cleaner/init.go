@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// allowListFileName is the allow-list written by init, next to .mendix.
+const allowListFileName = "allowed-libs.yaml"
+
+// mendixVersion returns the runtime version the project was last built
+// with, or an empty string.
+func mendixVersion(projectDir string) string {
+	b, err := ioutil.ReadFile(filepath.Join(projectDir, "deployment", "model", "metadata.json"))
+	if err != nil {
+		return ""
+	}
+	metadata := struct {
+		RuntimeVersion string
+	}{}
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return ""
+	}
+	return metadata.RuntimeVersion
+}
+
+// initProject implements the init command. It writes a starter project
+// config, an allow-list of the packages in userlib today and a .cleanerignore
+// naming the JARs that cannot be identified. Existing files are left alone.
+func initProject(targetDir string, mode string, policy Policy) {
+	dir := projectDir(targetDir, findProjectConfig(targetDir))
+	version := mendixVersion(dir)
+	filePaths := listAllFiles(targetDir)
+	jars := listAllJars(filePaths, mode)
+	keepJars := decideJarsToKeep(jars, mode, policy)
+
+	description := filepath.Base(dir)
+	if version != "" {
+		description += ", Mendix " + version
+	}
+	description += fmt.Sprintf(", %d JAR(s) in userlib", len(jars))
+
+	unidentified := []string{}
+	for _, jar := range jars {
+		if isUnidentified(jar) {
+			unidentified = append(unidentified, jar.fileName)
+		}
+	}
+	sort.Strings(unidentified)
+
+	writeStarterFile(filepath.Join(dir, ".mendix", "config.yaml"), starterConfig(description, keepJars, len(unidentified) > 0))
+	writeStarterFile(filepath.Join(dir, allowListFileName), starterAllowList(description, keepJars))
+	writeStarterFile(filepath.Join(targetDir, ignoreFileName), starterIgnoreFile(unidentified))
+}
+
+func writeStarterFile(path string, content string) {
+	if fileExists(path) {
+		log.Warningf("%v exists, left unchanged", path)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		fatalf("Unable to write %v: %v", path, err)
+	}
+	log.Infof("Wrote %v", path)
+}
+
+func starterConfig(description string, keepJars map[string]JarProperties, unidentified bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Settings of mendix-userlib-cleaner for %v.\n", description)
+	b.WriteString("# Each key is the name of a flag, relative paths are resolved against the\n")
+	b.WriteString("# project directory. Flags given on the command line take precedence.\n")
+	fmt.Fprintf(&b, "%v:\n", projectConfigSection)
+	b.WriteString("  mode: auto\n\n")
+	b.WriteString("  # Keep a package at a fixed version, whatever else is present.\n")
+	b.WriteString("  # keep:\n  #   - org.apache.velocity=1.7\n\n")
+	fmt.Fprintf(&b, "  # Approved packages, generated from the JARs kept today. Review %v,\n", allowListFileName)
+	b.WriteString("  # then enable it: JARs of other packages are removed with --clean.\n")
+	fmt.Fprintf(&b, "  # allow-list: %v\n\n", allowListFileName)
+	b.WriteString("  # Version ranges packages must stay within, and known vulnerable versions.\n")
+	b.WriteString("  # constraints: constraints.yaml\n  # vulnerabilities: vulnerabilities.yaml\n\n")
+
+	critical := []string{}
+	for _, jar := range keepJars {
+		if isCriticalPackage(jar.fullKey()) {
+			critical = append(critical, jar.fullKey())
+		}
+	}
+	sort.Strings(critical)
+	if len(critical) > 0 {
+		fmt.Fprintf(&b, "  # Present critical packages, only removed after confirming each file:\n  # %v\n", strings.Join(critical, ", "))
+	}
+	b.WriteString("  # critical-packages:\n")
+	for _, prefix := range defaultCriticalPackages {
+		fmt.Fprintf(&b, "  #   - %v\n", prefix)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("  # Outputs of each run.\n")
+	b.WriteString("  # report: deployment/userlib-report.json\n")
+	b.WriteString("  history: .mendix/userlib-history.json\n")
+	if unidentified {
+		b.WriteString("  review-queue: .mendix/userlib-review.json\n")
+	} else {
+		b.WriteString("  # review-queue: .mendix/userlib-review.json\n")
+	}
+	return b.String()
+}
+
+func starterAllowList(description string, keepJars map[string]JarProperties) string {
+	packageNames := []string{}
+	for packageName, jar := range keepJars {
+		if !isUnidentified(jar) {
+			packageNames = append(packageNames, packageName)
+		}
+	}
+	sort.Strings(packageNames)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Approved packages for %v.\n", description)
+	b.WriteString("# Generated from the versions kept today: each may be updated within its\n")
+	b.WriteString("# major version. Tighten or widen the ranges, then enable --allow-list.\n")
+	for _, packageName := range packageNames {
+		version := keepJars[packageName].version
+		fmt.Fprintf(&b, "%v: %q\n", packageName, starterRange(version))
+	}
+	if len(packageNames) == 0 {
+		b.WriteString("{}\n")
+	}
+	return b.String()
+}
+
+// starterRange accepts the version and newer ones with the same major
+// version.
+func starterRange(version string) string {
+	major := regexp.MustCompile("^[0-9]+").FindString(version)
+	if major == "" {
+		return version
+	}
+	next, _ := strconv.Atoi(major)
+	return fmt.Sprintf(">=%v <%d", version, next+1)
+}
+
+func starterIgnoreFile(unidentified []string) string {
+	var b strings.Builder
+	b.WriteString("# Files in userlib that mendix-userlib-cleaner never analyzes or removes,\n")
+	b.WriteString("# with the patterns of .gitignore, e.g. vendor-sdk-*.jar or !vendor-sdk-tools.jar\n")
+	if len(unidentified) > 0 {
+		b.WriteString("\n# Unidentified JARs, they are tracked in the review queue. Uncomment to\n")
+		b.WriteString("# stop reporting one that is known to be fine.\n")
+		for _, fileName := range unidentified {
+			b.WriteString("# " + fileName + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	moduleExplicit  = "explicit module"
+	moduleAutomatic = "automatic module"
+	moduleClasspath = "classpath"
+)
+
+// versionedEntriesPrefix holds the class trees of multi-release JARs, which
+// override the classes at the root on newer Java versions.
+const versionedEntriesPrefix = "META-INF/versions/"
+
+var versionedModuleInfo = regexp.MustCompile(`^META-INF/versions/[0-9]+/module-info\.class$`)
+
+// inspectEntries records properties of the JAR that follow from its entries
+// rather than from its identity.
+func inspectEntries(jarProp *JarProperties, archive *zip.Reader, logger jarLog) {
+	if jarProp.filePath == "" {
+		return
+	}
+	jarProp.moduleKind = moduleClasspath
+	explicitModule := false
+	explicitName := ""
+	platforms := make(map[string]bool)
+	jarProp.services = make(map[string][]string)
+	for _, f := range archive.File {
+		if platform := nativePlatform(f.Name); platform != "" {
+			platforms[platform] = true
+		}
+		if isResource(f.Name) {
+			jarProp.resources = append(jarProp.resources, f.Name)
+		}
+		if f.Name == "module-info.class" || versionedModuleInfo.MatchString(f.Name) {
+			explicitModule = true
+			if name, _, err := parseModuleInfo(extractEntry(f)); err == nil && (f.Name == "module-info.class" || explicitName == "") {
+				explicitName = name
+			}
+		} else if f.Name == "META-INF/MANIFEST.MF" {
+			text := string(extractEntry(f))
+			if name := manifestValue(text, "Automatic-Module-Name"); name != "" {
+				jarProp.moduleKind = moduleAutomatic
+				jarProp.moduleName = name
+			}
+			parseFragmentHost(jarProp, manifestValue(text, "Fragment-Host"))
+			jarProp.multiRelease = strings.EqualFold(manifestValue(text, "Multi-Release"), "true")
+		} else if strings.HasPrefix(f.Name, servicesPrefix) && !strings.HasSuffix(f.Name, "/") {
+			if providers := parseServiceProviders(string(extractEntry(f))); len(providers) > 0 {
+				jarProp.services[strings.TrimPrefix(f.Name, servicesPrefix)] = providers
+			}
+		} else if isGradleModule(f.Name) {
+			jarProp.variants = parseGradleModule(jarProp.filePath, string(extractEntry(f))).variants
+		}
+	}
+	jarProp.classVersion = requiredClassVersion(archive)
+	if jarProp.classVersion != 0 {
+		logger.Debugf("%v requires Java %v", jarProp.fileName, javaRelease(jarProp.classVersion))
+	}
+	if explicitModule {
+		jarProp.moduleKind = moduleExplicit
+		jarProp.moduleName = explicitName
+	}
+	logger.Debugf("Module type of %v: %v", jarProp.fileName, jarProp.moduleKind)
+	if jarProp.multiRelease {
+		logger.Debugf("%v is a multi-release JAR", jarProp.fileName)
+	}
+
+	for platform := range platforms {
+		jarProp.natives = append(jarProp.natives, platform)
+	}
+	sort.Strings(jarProp.natives)
+	if len(jarProp.natives) > 0 {
+		logger.Infof("Native libraries in %v for: %v", jarProp.fileName, strings.Join(jarProp.natives, ", "))
+	}
+}
+
+var nativeSeparators = regexp.MustCompile(`[/._-]+`)
+
+var nativeOperatingSystems = []struct {
+	name   string
+	tokens []string
+}{
+	{"windows", []string{"windows", "win32", "win64", "win"}},
+	{"macos", []string{"darwin", "macos", "macosx", "osx", "mac"}},
+	{"linux", []string{"linux"}},
+	{"freebsd", []string{"freebsd"}},
+}
+
+var nativeArchitectures = []struct {
+	name   string
+	tokens []string
+}{
+	{"x86_64", []string{"x86_64", "x86-64", "amd64", "x64"}},
+	{"aarch64", []string{"aarch64", "arm64"}},
+	{"x86", []string{"x86", "i386", "i686", "x32"}},
+	{"arm", []string{"arm", "armv7", "armhf"}},
+	{"ppc64le", []string{"ppc64le"}},
+	{"s390x", []string{"s390x"}},
+}
+
+// nativePlatform returns the platform of a native library entry, derived from
+// the directory names and the file extension, or an empty string if the entry
+// is not a native library.
+func nativePlatform(name string) string {
+	lower := strings.ToLower(name)
+	extension := path.Ext(lower)
+	if extension != ".so" && extension != ".dll" && extension != ".dylib" && extension != ".jnilib" && !strings.Contains(lower, ".so.") {
+		return ""
+	}
+
+	// x86_64 and x86-64 are split into two tokens as well
+	joined := "/" + strings.Join(nativeSeparators.Split(strings.TrimSuffix(lower, extension), -1), "/") + "/"
+	joined = strings.Replace(joined, "/x86/64/", "/x86_64/", -1)
+
+	operatingSystem := ""
+	for _, candidate := range nativeOperatingSystems {
+		for _, token := range candidate.tokens {
+			if strings.Contains(joined, "/"+token+"/") {
+				operatingSystem = candidate.name
+			}
+		}
+		if operatingSystem != "" {
+			break
+		}
+	}
+	if operatingSystem == "" {
+		switch extension {
+		case ".dll":
+			operatingSystem = "windows"
+		case ".dylib", ".jnilib":
+			operatingSystem = "macos"
+		default:
+			operatingSystem = "linux"
+		}
+	}
+
+	for _, candidate := range nativeArchitectures {
+		for _, token := range candidate.tokens {
+			if strings.Contains(joined, "/"+token+"/") {
+				return operatingSystem + "-" + candidate.name
+			}
+		}
+	}
+	return operatingSystem
+}
+
+// checkNativePlatforms warns when a removed duplicate carries native libraries
+// for a platform the kept JAR does not support.
+func checkNativePlatforms(jars []JarProperties, keepJars map[string]JarProperties) {
+	for _, jar := range jars {
+		keepJar, ok := keepJars[jar.key()]
+		if !ok || keepJar.filePath == jar.filePath {
+			continue
+		}
+		missing := []string{}
+		for _, platform := range jar.natives {
+			if !contains(keepJar.natives, platform) {
+				missing = append(missing, platform)
+			}
+		}
+		if len(missing) > 0 {
+			log.Warningf("Removing %v drops native libraries for %v, kept %v does not provide them", jar.fileName, strings.Join(missing, ", "), keepJar.fileName)
+			addFinding(Finding{Type: "native-platform-dropped", Severity: severityWarning, Package: jar.key(),
+				Message:      fmt.Sprintf("Removing %v drops native libraries for %v", jar.fileName, strings.Join(missing, ", ")),
+				Files:        []string{jar.filePath, keepJar.filePath},
+				SuggestedFix: pinFix(jar.key(), jar.version, "keep the JAR providing natives for "+strings.Join(missing, ", "))})
+		}
+	}
+}
+
+// checkModuleKinds warns about duplicates that differ in module type, since
+// replacing one by the other changes how newer Java versions load them.
+func checkModuleKinds(jars []JarProperties, keepJars map[string]JarProperties) {
+	for _, jar := range jars {
+		keepJar, ok := keepJars[jar.key()]
+		if !ok || keepJar.filePath == jar.filePath || keepJar.moduleKind == jar.moduleKind {
+			continue
+		}
+		log.Warningf("Duplicates of %v differ in module type: %v (%v) vs. kept %v (%v)", jar.key(), jar.fileName, jar.moduleKind, keepJar.fileName, keepJar.moduleKind)
+		addFinding(Finding{Type: "module-type-mismatch", Severity: severityWarning, Package: jar.key(),
+			Message:      fmt.Sprintf("%v (%v) differs in module type from kept %v (%v)", jar.fileName, jar.moduleKind, keepJar.fileName, keepJar.moduleKind),
+			Files:        []string{jar.filePath, keepJar.filePath},
+			SuggestedFix: pinFix(jar.key(), keepJar.version, "verify the app runs with the kept module type, then pin it")})
+	}
+}
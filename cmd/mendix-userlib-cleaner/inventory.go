@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// inventory implements the inventory command: a sorted list of the JARs in
+// targetDir with their coordinates and checksums, meant to be committed next
+// to userlib so Git diffs show library changes. The output is the same for
+// the same JARs, it contains no dates.
+func inventory(targetDir string, mode string, path string) {
+	jarsByPath := make(map[string]JarProperties)
+	filePaths := listAllFiles(targetDir)
+	for _, jar := range listAllJars(filePaths, mode) {
+		jarsByPath[jar.filePath] = jar
+	}
+
+	lines := []string{}
+	for _, filePath := range filePaths {
+		if !isArchive(filePath) {
+			continue
+		}
+		coordinates := "unidentified"
+		if _, ok := readLFSPointer(filePath); ok {
+			coordinates = "lfs-pointer"
+		} else if jar, ok := jarsByPath[filePath]; !ok {
+			coordinates = "unreadable"
+		} else if !isUnidentified(jar) {
+			coordinates = inventoryCoordinates(jar)
+		}
+		checksum, err := fileSHA256(filePath)
+		if err != nil {
+			fatalf("Unable to hash %v: %v", filepath.Base(filePath), err)
+		}
+		lines = append(lines, fmt.Sprintf("%v %v %v", relativePath(targetDir, filePath), coordinates, checksum))
+	}
+	sort.Strings(lines)
+
+	content := "# JARs in userlib: file, group:artifact:version and SHA-256.\n" +
+		"# Generated by mendix-userlib-cleaner inventory, do not edit.\n" +
+		strings.Join(lines, "\n") + "\n"
+	if path == "" {
+		os.Stdout.WriteString(content)
+		return
+	}
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		fatalf("Unable to write inventory: %v", err)
+	}
+	log.Infof("Wrote %d JAR(s) to %v", len(lines), path)
+}
+
+func inventoryCoordinates(jar JarProperties) string {
+	parts := []string{}
+	if jar.group != "" {
+		parts = append(parts, jar.group)
+	}
+	parts = append(parts, jar.artifact)
+	if jar.version != "" {
+		parts = append(parts, jar.version)
+	}
+	return strings.Join(parts, ":")
+}
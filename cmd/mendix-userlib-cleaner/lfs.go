@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lfsPointerPrefix starts the text file Git LFS leaves in place of a file
+// that has not been downloaded (smudged) yet.
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+
+// lfsPointer is a JAR that is only a Git LFS pointer.
+type lfsPointer struct {
+	oid  string
+	size string
+}
+
+// readLFSPointer tells whether the file is a Git LFS pointer. Pointers are
+// small text files, larger files are not read.
+func readLFSPointer(filePath string) (lfsPointer, bool) {
+	pointer := lfsPointer{}
+	info, err := os.Stat(filePath)
+	if err != nil || info.Size() > 1024 {
+		return pointer, false
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return pointer, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != lfsPointerPrefix {
+		return pointer, false
+	}
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "oid":
+			pointer.oid = fields[1]
+		case "size":
+			pointer.size = fields[1]
+		}
+	}
+	return pointer, true
+}
+
+func lfsPointerFinding(filePath string, pointer lfsPointer) Finding {
+	size := ""
+	if pointer.size != "" {
+		size = fmt.Sprintf(" of %v bytes", pointer.size)
+	}
+	return Finding{Type: "lfs-pointer", Severity: severityCritical,
+		Message: fmt.Sprintf("%v is a Git LFS pointer, the JAR%v was not downloaded", filepath.Base(filePath), size),
+		Files:   []string{filePath}, SuggestedFix: &SuggestedFix{Action: "replace-file", Target: filePath, Detail: "run git lfs pull, or use --lfs-pull"}}
+}
+
+// pullLFSPointers downloads the archives of the listed files in targetDir
+// that are Git LFS pointers with git lfs pull, so they are analyzed like the
+// others. If that fails the pointers are reported as usual.
+func pullLFSPointers(targetDir string, filePaths []string) {
+	pointers := []string{}
+	for _, filePath := range filePaths {
+		if !isArchive(filePath) {
+			continue
+		}
+		if _, ok := readLFSPointer(filePath); ok {
+			pointers = append(pointers, filePath)
+		}
+	}
+	if len(pointers) == 0 {
+		return
+	}
+
+	output, err := exec.Command("git", "-C", targetDir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		degradeCheck("git lfs pull", fmt.Sprintf("%v is not in a Git repository: %v", targetDir, err))
+		return
+	}
+	root := strings.TrimSpace(string(output))
+	includes := []string{}
+	for _, filePath := range pointers {
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			fatal(err)
+		}
+		if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+			absPath = resolved
+		}
+		rel, err := filepath.Rel(root, absPath)
+		if err != nil {
+			fatal(err)
+		}
+		includes = append(includes, filepath.ToSlash(rel))
+	}
+
+	log.Infof("Pulling %d JAR(s) from Git LFS", len(pointers))
+	command := exec.Command("git", "-C", root, "lfs", "pull", "--include", strings.Join(includes, ","))
+	if output, err := command.CombinedOutput(); err != nil {
+		log.Warningf("git lfs pull failed: %v", strings.TrimSpace(string(output)))
+		degradeCheck("git lfs pull", err.Error())
+	}
+}
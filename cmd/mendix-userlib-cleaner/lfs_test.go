@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPullLFSPointersInListedFiles(t *testing.T) {
+	defer viper.Reset()
+	defer resetDegradedChecks()
+	target := t.TempDir()
+	pointer := lfsPointerPrefix + "\noid sha256:4d7a2146\nsize 12345\n"
+	if err := os.MkdirAll(filepath.Join(target, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(target, "vendor", "sdk-1.0.jar"), []byte(pointer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, recursive := range []bool{false, true} {
+		resetDegradedChecks()
+		viper.Set("recursive", recursive)
+		pullLFSPointers(target, listAllFiles(target))
+		// the temporary target is not in a Git repository, so pulling fails
+		if attempted := len(degradedChecks()) > 0; attempted != recursive {
+			t.Errorf("with --recursive=%v, pulling vendor/sdk-1.0.jar was attempted: %v", recursive, attempted)
+		}
+	}
+}
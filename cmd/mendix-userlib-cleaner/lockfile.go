@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Lockfile pins the JARs approved to ship, with their checksums.
+type Lockfile struct {
+	Files []LockedFile `json:"files"`
+}
+
+// LockedFile is a JAR by its path relative to the target, which is its file
+// name unless it is in a subdirectory scanned with --recursive.
+type LockedFile struct {
+	FileName string `json:"fileName"`
+	Package  string `json:"package"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+}
+
+// writeLockfile records the JARs that remain in targetDir after cleaning:
+// the kept ones, and the duplicates cleaning leaves in place, like held and
+// guarded ones and those outside of --module.
+func writeLockfile(targetDir string, mode string, policy Policy, path string) {
+	if path == "" {
+		fatal("Use --lockfile to set the path of the lockfile")
+	}
+	projectConfig := ""
+	if !viper.GetBool("ignore-project-config") {
+		projectConfig = findProjectConfig(targetDir)
+	}
+	filePaths := listAllFiles(targetDir)
+	jars := listAllJars(filePaths, mode)
+	cleaned, keepJars := analyzeJars(targetDir, projectConfig, mode, policy, filePaths, jars)
+	inScope := make(map[string]bool)
+	for _, jar := range cleaned {
+		inScope[jar.filePath] = true
+	}
+	holds := activeHolds(time.Now())
+
+	lockfile := Lockfile{Files: []LockedFile{}}
+	for _, jar := range jars {
+		if inScope[jar.filePath] && !staysAfterCleaning(jar, keepJars, holds) {
+			continue
+		}
+		checksum, err := fileSHA256(jar.filePath)
+		if err != nil {
+			fatalf("Unable to hash %v: %v", jar.fileName, err)
+		}
+		lockfile.Files = append(lockfile.Files, LockedFile{relativePath(targetDir, jar.filePath), jar.key(), jar.version, checksum})
+	}
+	sort.Slice(lockfile.Files, func(i, j int) bool { return lockfile.Files[i].FileName < lockfile.Files[j].FileName })
+
+	b, err := json.MarshalIndent(lockfile, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(path, b, 0644); err != nil {
+		fatalf("Unable to write lockfile: %v", err)
+	}
+	log.Infof("Locked %d JAR(s) in %v", len(lockfile.Files), path)
+}
+
+// verifyLockfile checks that the JARs in targetDir match the lockfile: none
+// missing and equal checksums. With strict, JARs not in the lockfile fail the
+// verification too, otherwise they are warned about. Nothing is changed.
+func verifyLockfile(targetDir string, path string, strict bool) {
+	if path == "" {
+		fatal("Use --lockfile to set the path of the lockfile")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read lockfile: %v", err)
+	}
+	lockfile := Lockfile{}
+	if err := json.Unmarshal(b, &lockfile); err != nil {
+		fatalf("Unable to parse lockfile %v: %v", path, err)
+	}
+
+	present := make(map[string]string)
+	for _, filePath := range listAllFiles(targetDir) {
+		if isArchive(filePath) {
+			present[relativePath(targetDir, filePath)] = filePath
+		}
+	}
+
+	failures := 0
+	for _, locked := range lockfile.Files {
+		filePath, ok := present[locked.FileName]
+		if !ok {
+			log.Errorf("Missing: %v (%v %v)", locked.FileName, locked.Package, locked.Version)
+			failures++
+			continue
+		}
+		delete(present, locked.FileName)
+		checksum, err := fileSHA256(filePath)
+		if err != nil {
+			log.Errorf("Unable to hash %v: %v", locked.FileName, err)
+			failures++
+		} else if checksum != locked.SHA256 {
+			log.Errorf("Checksum mismatch: %v", locked.FileName)
+			failures++
+		}
+	}
+	extras := []string{}
+	for fileName := range present {
+		extras = append(extras, fileName)
+	}
+	sort.Strings(extras)
+	for _, fileName := range extras {
+		if strict {
+			log.Errorf("Not in lockfile: %v", fileName)
+			failures++
+		} else {
+			log.Warningf("Not in lockfile: %v", fileName)
+		}
+	}
+
+	if failures > 0 {
+		fatalf("Verification against %v failed with %d problem(s)", path, failures)
+	}
+	log.Infof("%v matches %v: %d JAR(s), %d extra", targetDir, path, len(lockfile.Files), len(extras))
+}
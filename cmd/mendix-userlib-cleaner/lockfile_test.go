@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLockfileKeepsHeldDuplicates(t *testing.T) {
+	defer viper.Reset()
+	defer resetFindings()
+	target := t.TempDir()
+	copyFixtureJars(t, target, "checker-qual-2.5.2.jar", "checker-qual-2.5.3.jar", "junit-4.11.jar")
+	lockPath := filepath.Join(t.TempDir(), "userlib.lock")
+
+	locked := func() []string {
+		writeLockfile(target, "auto", Policy{}, lockPath)
+		b, err := ioutil.ReadFile(lockPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lockfile := Lockfile{}
+		if err := json.Unmarshal(b, &lockfile); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, file := range lockfile.Files {
+			names = append(names, file.FileName)
+		}
+		return names
+	}
+
+	if names := locked(); len(names) != 2 || contains(names, "checker-qual-2.5.2.jar") {
+		t.Errorf("locked %v, want the duplicate left out", names)
+	}
+	viper.Set("hold", []string{"org.checkerframework.checker.units=2099-01-01"})
+	if names := locked(); len(names) != 3 {
+		t.Errorf("locked %v, want the held duplicate as well", names)
+	}
+}
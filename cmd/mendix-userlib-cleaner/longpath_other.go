@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// longPath returns the path unchanged, only Windows limits the length of
+// paths.
+func longPath(path string) string {
+	return path
+}
+
+func displayPath(path string) string {
+	return path
+}
@@ -0,0 +1,37 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const extendedPrefix = `\\?\`
+
+// longPath turns a path into an absolute extended-length path, like
+// \\?\C:\project\userlib or \\?\UNC\server\share\project\userlib for a
+// network share, so files deeper than MAX_PATH (260 characters) can be
+// listed, hashed and removed.
+func longPath(path string) string {
+	if strings.HasPrefix(path, extendedPrefix) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return extendedPrefix + `UNC\` + abs[2:]
+	}
+	return extendedPrefix + abs
+}
+
+// displayPath shows a path without the extended-length prefix.
+func displayPath(path string) string {
+	if strings.HasPrefix(path, extendedPrefix+`UNC\`) {
+		return `\\` + path[len(extendedPrefix)+4:]
+	}
+	return strings.TrimPrefix(path, extendedPrefix)
+}
@@ -3,16 +3,20 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
-	"regexp"
+	"sort"
 	"strings"
 
 	"archive/zip"
 
 	"flag"
 	"io"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
-	"strconv"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/op/go-logging"
 	"github.com/spf13/pflag"
@@ -25,35 +29,175 @@ var format = logging.MustStringFormatter(
 	`%{color}%{time:15:04:05.000} %{shortfunc} ▶ %{level:.4s} %{id:03x}%{color:reset} %{message}`,
 )
 
+var commands = []struct {
+	name        string
+	description string
+}{
+	{"fixture", "Generate a synthetic userlib in --target for demos, training and benchmarks."},
+	{"simulate", "Report the outcome of hypothetical --add and --remove changes without touching disk."},
+	{"diagnose", "Explain class loading errors from --error-log using the JARs in --target."},
+	{"which-jar", "List every JAR providing the given fully qualified class name(s)."},
+	{"doctor", "Run all health checks on --target without changing anything and print a prioritized summary."},
+	{"merge-reports", "Combine the given JSON reports into --report, rendered as JSON, HTML or CSV by its extension."},
+	{"lock", "Write the JARs that remain after cleaning --target, with their checksums, to --lockfile."},
+	{"verify", "Check that the JARs in --target match --lockfile, without changing anything. Fails on extra JARs with --strict."},
+	{"backups", "List the files in --quarantine that can be recovered (backups list)."},
+	{"serve", "Serve GET /capabilities over HTTP on --listen."},
+	{"inventory", "Print a sorted list of the JARs in --target with coordinates and SHA-256, or write it to --write for committing."},
+	{"db update", "Refresh the fingerprint database identifying JARs without metadata from the Maven repository, into the cache directory or --write."},
+	{"shared-libs", "Compare the userlib directories given as arguments, e.g. of the apps on one server, and list the JARs identical in several with the storage a shared copy saves. JSON with --report."},
+	{"init", "Write a commented starter project config, allow-list and .cleanerignore for the project of --target."},
+	{"triage", "List the JARs in --target that cannot be identified and assign their coordinates interactively, saved to --triage for future runs."},
+	{"explain-rule", "Explain the given rule IDs like MXCLEAN001 or finding types, or list all rules."},
+}
+
 type JarProperties struct {
-	version       string
-	versionNumber int
-	filePath      string
-	fileName      string
-	packageName   string
-	name          string
-	vendor        string
-	license       string
+	Coordinates
+	filePath            string
+	fileName            string
+	name                string
+	vendor              string
+	license             string
+	owner               string
+	justification       string
+	moduleKind          string
+	moduleName          string
+	multiRelease        bool
+	shaded              []string
+	relocation          string
+	aliasOf             string
+	confidence          string
+	alternatives        []string
+	copyOf              string
+	nested              []JarProperties
+	classVersion        int
+	resourceOnly        bool
+	natives             []string
+	fragmentHost        string
+	fragmentHostVersion string
+	size                int64
+	modules             []string
+	variants            []string
+	services            map[string][]string
+	resources           []string
+	requirements        map[string]versionRange
+	firstSeen           time.Time
+	lastChanged         time.Time
 }
 
 func main() {
 
-	flag.String("target", ".", "Path to userlib.")
+	pflag.StringSlice("target", []string{"."}, "Path to userlib. Repeat it or separate paths by commas to clean several in one run. A project root with a .mpr file stands for its userlib, vendorlib and deployment/model/lib. A userlib in S3 or Azure Blob Storage is given as s3://bucket/path or az://account/container/path.")
 	flag.Bool("clean", false, "Turn on to actually remove the duplicate JARs.")
+	flag.Bool("stdio", false, "Turn on to serve the scan, inspect, plan and apply operations as JSON-RPC on stdin and stdout.")
+	pflag.StringSlice("critical-packages", defaultCriticalPackages, "Packages whose files are only removed after confirming each file. Can be repeated.")
 	flag.Bool("verbose", false, "Turn on to see debug information.")
+	flag.Bool("ignore-project-config", false, "Turn on to ignore the mendix-cleaner section of .mendix/config.yaml.")
+	flag.String("report-dir", "", "Writable directory for the report and other output with a relative path, for a read-only target. Writes report.json unless --report is set.")
+	flag.String("fail-on", "none", "Exit with a non-zero code for findings at this level or above: none, critical (2), warning (3) or duplicate (4).")
+	pflag.StringSlice("exporter", []string{}, "Outputs of the run: console, json, html, sarif, metrics, webhook or s3. Defaults to json with --report and metrics with --metrics-csv.")
+	flag.String("webhook-url", "", "URL the webhook exporter posts the JSON report to.")
+	flag.String("s3-url", "", "Presigned URL the s3 exporter uploads the JSON report to.")
+	flag.String("report", "", "Write a JSON report with all JARs and findings to this path.")
+	flag.String("changelog", "", "Write the library changes of the clean, like updated versions and removed duplicates, as Markdown for release notes to this path.")
+	flag.String("group-by", "module", "Group the JARs in the report by module, vendor or package.")
+	flag.String("review-queue", "", "Path to a JSON file tracking unidentified JARs across runs until they are identified or removed.")
+	flag.String("history", "", "Path to a JSON file recording when each package first appeared and last changed, across runs.")
+	flag.String("metrics-csv", "", "Append a row with the metrics of this run to this CSV file.")
+	flag.Bool("show-skipped", false, "List every file in the target that was not processed and why.")
+	flag.String("tmp-dir", "", "Directory in which the per-run temporary directory is created. Defaults to the system temporary directory.")
+	flag.Int("jobs", 0, "Number of JARs to scan in parallel, 0 means one per CPU.")
+	flag.Int64("io-throttle", 0, "Limit reading JARs to this many bytes per second, 0 means unlimited.")
+	flag.Int("nice", 0, "Run with this niceness (1-19) to leave resources to other processes, 0 keeps the priority.")
+	flag.String("quarantine", "", "Move removed files into a folder per run in this directory instead of deleting them.")
+	flag.String("backup-retention", "", "Prune quarantined files older than this, e.g. 30d, 2w or 12h, and empty quarantine folders.")
+	flag.Bool("lfs-pull", false, "Turn on to download JARs that are Git LFS pointers with git lfs pull before the scan.")
+	flag.String("managed-dependencies", "", "Path to the Gradle lockfile or verification metadata of Mendix 10 managed dependencies. Found in the project by default.")
+	flag.String("owners", "", "Path to a YAML file with the owner and justification of JARs. Defaults to userlib.owners.yaml in the project.")
+	flag.String("aliases", "", "Path to a YAML file mapping package names or group:artifact of forks and renamed artifacts to the library they are treated as.")
+	pflag.StringSlice("include", []string{}, "Glob patterns of the JARs to analyze, by file name or path relative to --target, e.g. mx-*.jar. Can be repeated.")
+	pflag.StringSlice("exclude", []string{}, "Glob patterns of files that are neither analyzed nor removed, e.g. commons-*. Can be repeated.")
+	flag.String("files", "", "Path to a newline-separated list of the files in --target to analyze instead of all, or - for stdin, e.g. from git diff --name-only.")
+	flag.String("image-tar", "", "Analyze the userlib of the app in a Docker image saved with docker save, instead of --target. The image is not changed.")
+	flag.String("repackage", "", "Write a copy of the .mda or .mpk given as --target to this path, without the JARs removed by --clean. All other entries are kept as they are.")
+	flag.Bool("watch", false, "Turn on to keep running and analyze --target again whenever JARs are added, changed or removed, printing only new findings. Files are only removed by the first run.")
+	flag.String("symlinks", symlinksFollow, "How to handle symlinks in --target: follow analyzes them like files, skip leaves them out, report also reports them and never removes them. Files symlinks point to are never removed.")
+	flag.Bool("recursive", false, "Turn on to also scan the subdirectories of --target, like folders of legacy module imports.")
+	flag.Int("max-depth", 0, "Number of subdirectory levels scanned with --recursive, 0 for no limit.")
+	flag.String("triage", "", "Path to the YAML file with the coordinates assigned to unidentified JARs by triage. Defaults to userlib.triage.yaml in the project.")
+	flag.Bool("require-owners", false, "Turn on to report JARs no Marketplace module requires that have no owner and justification.")
+	flag.Bool("dedup-shaded", false, "Turn on to deduplicate shaded JARs bundling several libraries like other JARs, by the library their metadata names.")
+	flag.Bool("content-hash", false, "Turn on to treat byte-identical JARs as duplicates, even when their metadata differs or cannot be read.")
+	flag.String("min-confidence", confidenceLowest, "Only remove duplicates identified with at least this confidence: lowest, low, medium or high. Others are reported instead.")
+	flag.Int("java-version", 0, "Java version of the runtime, e.g. 11. Kept JARs compiled for a newer Java are reported.")
+	flag.Bool("nested", false, "Turn on to identify the JARs nested in JARs, e.g. under lib/ or BOOT-INF/lib/, list them in the report and warn about those also in userlib.")
+	flag.Bool("ignore-renamed-copies", false, "Turn on to leave files like foo.jar.bak alone that have the same content as a JAR. By default they are removed as its duplicates.")
+	flag.Bool("guard-dependencies", false, "Turn on to keep JARs that are the only provider of a package a kept JAR imports or depends on, and report them instead.")
+	flag.Bool("check-class-overlap", false, "Turn on to report kept JARs of different libraries that contain the same classes.")
+	flag.Bool("check-split-packages", false, "Turn on to report Java packages whose classes are spread over several kept JARs.")
+	flag.Bool("check-outdated", false, "Turn on to look up newer releases of the kept JARs in the Maven repository.")
+	flag.Bool("verify-checksums", false, "Turn on to compare the kept JARs with the checksums published in the Maven repository.")
+	flag.String("repository-url", "", "Base URL of the Maven repository for lookups, e.g. an internal Nexus or Artifactory. Defaults to Maven Central.")
+	flag.String("repository-user", "", "User for basic authentication with the repository. The password is read from "+repositoryPasswordEnv+".")
+	flag.String("repository-password", "", "Password for the repository, preferably given in "+repositoryPasswordEnv+" instead.")
+	flag.String("repository-token", "", "Bearer token for the repository, preferably given in "+repositoryTokenEnv+" instead.")
+	flag.String("cache-dir", "", "Directory for the persistent class index. Defaults to the user cache directory.")
+	flag.String("identity", identityGroupArtifact, "Which coordinates make JARs duplicates: artifact, group-artifact or bundle.")
 	flag.String("mode", "auto", "Jar parsing mode. Supported options: auto, strict or path to m2ee-log.txt")
+	flag.String("allow-list", "", "Path to a YAML file with approved packages and version ranges. Everything else is reported and removed with --clean.")
+	flag.String("constraints", "", "Path to a YAML file with the version ranges packages must stay within for runtime compatibility.")
+	flag.String("vulnerabilities", "", "Path to a YAML file with known vulnerabilities and the versions they affect.")
+	flag.Bool("prefer-non-vulnerable", false, "Turn on to keep the newest unaffected duplicate instead of a vulnerable newer one.")
+	flag.String("import-mxbuild-log", "", "Path to an mxbuild or Studio Pro log to compare its duplicate library warnings with the findings.")
+	pflag.StringSlice("manifest-attributes", defaultNameAttributes, "Manifest attributes naming a JAR, in order of precedence.")
+	pflag.StringSlice("extensions", []string{"jar"}, "Extensions of the files analyzed like JARs, e.g. jar,zip,war,aar.")
+	pflag.StringArray("filename-pattern", []string{}, "Regular expression for file names of JARs without metadata, with the named groups version and optionally artifact and classifier, e.g. ^(?P<artifact>.+)_v(?P<version>[0-9.]+)\\.jar$. Can be repeated.")
+	pflag.StringSlice("packaging-preference", []string{packagingPlain, "bundle", "all", "shaded", "jar-with-dependencies"}, "Order of preference between packaging variants of the same version, plain being the JAR without classifier.")
+	pflag.StringSlice("module", []string{}, "Only report and clean the JARs required by this module. Can be repeated.")
+	pflag.StringSlice("keep", []string{}, "Force the version to keep for a package, e.g. org.apache.velocity=1.7. Can be repeated.")
+	pflag.StringSlice("hold", []string{}, "Keep the older duplicates of a package until the end of a day, e.g. org.apache.velocity=2025-06-30, to test a new version next to them. They are reported meanwhile. Can be repeated.")
+	flag.String("lockfile", "", "lock, verify: Path to the lockfile.")
+	flag.Bool("strict", false, "verify: Turn on to fail on JARs missing from the lockfile as well.")
+	flag.String("write", "", "inventory, db update: Path to write the inventory or fingerprint database to instead of stdout or the cache directory.")
+	flag.String("listen", "127.0.0.1:8765", "serve: Address to listen on.")
+	flag.Int("packages", 20, "fixture: Number of packages to generate.")
+	flag.Float64("duplicate-ratio", 0.3, "fixture: Share of packages that get older duplicate versions.")
+	flag.Int("broken", 0, "fixture: Number of corrupt JARs to generate.")
+	flag.Int("shaded", 1, "fixture: Number of shaded JARs bundling several packages.")
+	flag.Int64("seed", 1, "fixture: Random seed, the same seed generates the same userlib.")
+	pflag.StringSlice("add", []string{}, "simulate: JAR to add hypothetically. Can be repeated.")
+	pflag.StringSlice("remove", []string{}, "simulate: File name of a JAR to remove hypothetically. Can be repeated.")
+	flag.String("error-log", "", "diagnose: Path to a log with NoClassDefFoundError, NoSuchMethodError or LinkageError errors.")
+
+	pflag.Usage = usage
 
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 	viper.BindPFlags(pflag.CommandLine)
 
-	targetDir := viper.GetString("target")
+	targets := viper.GetStringSlice("target")
+	if len(targets) == 0 {
+		targets = []string{"."}
+	}
+	imageTar := viper.GetString("image-tar")
+	if imageTar != "" && !pflag.CommandLine.Changed("target") {
+		targets = []string{imageTar}
+	}
+	targetDir := targets[0]
+	viper.Set("target", targetDir)
+	projectConfig := ""
+	var projectConfigErr error
+	// the settings of one project would leak into the others
+	if !viper.GetBool("ignore-project-config") && sameProject(targets) {
+		if projectConfig = findProjectConfig(targetDir); projectConfig != "" {
+			projectConfigErr = loadProjectConfig(projectConfig)
+		}
+	}
 	mode := viper.GetString("mode")
 	clean := viper.GetBool("clean")
 	verbose := viper.GetBool("verbose")
-	regularModes := []string{"auto", "strict"}
 
-	backend := logging.NewLogBackend(os.Stderr, "", 0)
+	backend := logging.NewLogBackend(stderr, "", 0)
 	backendFormatter := logging.NewBackendFormatter(backend, format)
 
 	// Set the backends to be used.
@@ -64,226 +208,778 @@ func main() {
 		logging.SetLevel(logging.INFO, "main")
 	}
 
+	if projectConfigErr != nil {
+		fatalf("Unable to load settings from %v: %v", projectConfig, projectConfigErr)
+	} else if projectConfig != "" {
+		log.Infof("Using settings from %v", projectConfig)
+	}
+	if imageTar != "" && pflag.CommandLine.Changed("target") {
+		fatal("--image-tar is analyzed instead of --target, use only one of them")
+	}
+	for i := range targets {
+		if !isRemote(targets[i]) {
+			targets[i] = longPath(targets[i])
+		}
+	}
+	targets = discoverTargets(targets)
+	targetDir = targets[0]
+	viper.Set("target", targetDir)
+
+	if reportDir := viper.GetString("report-dir"); reportDir != "" {
+		applyReportDir(reportDir)
+	}
+	setIdentity(viper.GetString("identity"))
+	setNameAttributes(viper.GetStringSlice("manifest-attributes"))
+	setFileNamePatterns(viper.GetStringSlice("filename-pattern"))
+	setArchiveExtensions(viper.GetStringSlice("extensions"))
+	setSymlinkPolicy(viper.GetString("symlinks"))
+	if path := viper.GetString("aliases"); path != "" {
+		setAliases(path)
+	}
+	checkFailOn(viper.GetString("fail-on"))
+	exporters := selectExporters(viper.GetStringSlice("exporter"))
+	setIOThrottle(viper.GetInt64("io-throttle"))
+	if niceness := viper.GetInt("nice"); niceness < 0 || niceness > 19 {
+		// a negative niceness would raise the priority instead
+		fatalf("Invalid --nice value %v, expected 1 to 19 or 0 to keep the priority", niceness)
+	} else if niceness != 0 {
+		if err := setNiceness(niceness); err != nil {
+			log.Warningf("Unable to set niceness: %v", err)
+		}
+	}
+
+	removeTempDirOnSignal()
+	defer removeTempDir()
+	if imageTar != "" {
+		targets = []string{extractImage(targets[0])}
+	} else {
+		targets = extractPackages(targets)
+	}
+	targetDir = targets[0]
+	viper.Set("target", targetDir)
+
+	policy := loadPolicy()
+
+	if viper.GetBool("stdio") {
+		serveStdio(mode, policy)
+		return
+	}
+
+	args := pflag.Args()
+	if len(args) > 0 {
+		if len(targets) > 1 {
+			fatalf("The %v command takes a single --target", args[0])
+		}
+		switch args[0] {
+		case "fixture":
+			generateFixture(targetDir, viper.GetInt("packages"), viper.GetFloat64("duplicate-ratio"), viper.GetInt("broken"), viper.GetInt("shaded"), viper.GetInt64("seed"))
+		case "simulate":
+			simulate(targetDir, mode, viper.GetStringSlice("add"), viper.GetStringSlice("remove"), policy)
+		case "diagnose":
+			diagnose(targetDir, mode, viper.GetString("error-log"), policy)
+		case "which-jar":
+			whichJar(targetDir, mode, args[1:])
+		case "doctor":
+			doctor(targetDir, mode, policy)
+		case "merge-reports":
+			mergeReports(args[1:], viper.GetString("report"))
+		case "lock":
+			writeLockfile(targetDir, mode, policy, viper.GetString("lockfile"))
+		case "verify":
+			verifyLockfile(targetDir, viper.GetString("lockfile"), viper.GetBool("strict"))
+		case "backups":
+			backups(args[1:], viper.GetString("quarantine"))
+		case "triage":
+			triage(targetDir, mode)
+		case "explain-rule":
+			explainRule(args[1:])
+		case "serve":
+			serveHTTP(viper.GetString("listen"))
+		case "inventory":
+			inventory(targetDir, mode, viper.GetString("write"))
+		case "init":
+			initProject(targetDir, mode, policy)
+		case "db":
+			if len(args) < 2 || args[1] != "update" {
+				fatal("Unknown db command, use db update")
+			}
+			updateFingerprints(viper.GetString("write"))
+		case "shared-libs":
+			sharedLibraries(args[1:], mode, viper.GetString("report"))
+		default:
+			fatalf("Unknown command: %v", args[0])
+		}
+		return
+	}
+
+	if quarantine := viper.GetString("quarantine"); quarantine != "" {
+		allowMutationsIn(quarantine)
+		if value := viper.GetString("backup-retention"); value != "" {
+			retention, err := parseRetention(value)
+			if err != nil {
+				fatal(err)
+			}
+			pruneQuarantine(quarantine, retention)
+		}
+	} else if viper.GetString("backup-retention") != "" {
+		fatal("--backup-retention requires --quarantine")
+	}
+
+	if viper.GetBool("watch") {
+		checkWatch(targets)
+	}
+	total := runSummary{}
+	totalCount := 0
+	// a target may be analyzed without changes, like a running deployment
+	allClean := clean
+	checks := []DegradedCheck{}
+	labels := targetLabels(targets)
+	outputs := targetOutputs()
+	for i, targetDir := range targets {
+		label := ""
+		if len(targets) > 1 {
+			label = labels[i]
+			log.Infof("Target %d of %d: %v (%v)", i+1, len(targets), reportPath(targetDir), label)
+			useTarget(targetDir, label, outputs)
+			if !viper.GetBool("ignore-project-config") {
+				projectConfig = findProjectConfig(targetDir)
+			}
+		}
+		summary, count, cleaned := runTarget(targetDir, projectConfig, mode, clean, policy, exporters)
+		printSummary(cleaned, count, summary, degradedChecks(), label)
+		if viper.GetBool("watch") {
+			rememberFindings(targetDir)
+		}
+		allClean = allClean && cleaned
+		total.add(summary)
+		totalCount += count
+		for _, check := range degradedChecks() {
+			if label != "" {
+				check.Check = label + ": " + check.Check
+			}
+			checks = append(checks, check)
+		}
+	}
+	if len(targets) > 1 {
+		printSummary(allClean, totalCount, total, checks, "")
+	}
+	printResultLine(allClean, totalCount, total, len(checks))
+	if viper.GetBool("watch") {
+		watch(targets, labels, outputs, projectConfig, mode, policy, exporters)
+	}
+	if code := exitCode(viper.GetString("fail-on"), allClean, total); code != 0 {
+		removeTempDir()
+		os.Exit(code)
+	}
+}
+
+// runTarget cleans a single userlib and returns the summary of its findings,
+// the number of files removed, or that would be removed, and whether it was
+// cleaned rather than analyzed only.
+func runTarget(targetDir string, projectConfig string, mode string, clean bool, policy Policy, exporters []Exporter) (runSummary, int, bool) {
+	pkg, isPkg := extractedPackages[targetDir]
+	repackageTo := viper.GetString("repackage")
+	if extractedImages[targetDir] && clean {
+		log.Warningf("%v is a Docker image, it is analyzed without changes, clean the project it is built from", pkg)
+		clean = false
+	}
+	if repackageTo != "" && (!isPkg || extractedImages[targetDir]) {
+		fatalf("--repackage needs a .mda or .mpk as --target, %v is not", targetDir)
+	}
+	if repackageTo != "" {
+		checkRepackage(targetDir, repackageTo)
+	}
+	if _, ok := remoteTargets[targetDir]; ok && clean && viper.GetString("quarantine") != "" {
+		fatal("--quarantine keeps removed files on disk, it cannot be used to clean a remote target")
+	}
+	if isPkg && clean && repackageTo == "" {
+		log.Warningf("%v is a package, it is analyzed without changes, use --repackage to write a cleaned copy", pkg)
+		clean = false
+	}
+	clean = checkRunningDeployment(targetDir, clean)
+	guardTarget(targetDir)
 	filePaths := listAllFiles(targetDir)
+	if viper.GetBool("lfs-pull") {
+		pullLFSPointers(targetDir, filePaths)
+	}
 	jars := listAllJars(filePaths, mode)
+	if historyPath := viper.GetString("history"); historyPath != "" {
+		recordHistory(historyPath, jars)
+	}
+	jars, keepJars := analyzeJars(targetDir, projectConfig, mode, policy, filePaths, jars)
+	count := cleanJars(clean, filePaths, jars, keepJars)
+	if _, ok := remoteTargets[targetDir]; ok && clean {
+		removeRemoteObjects(targetDir)
+	}
+	if repackageTo != "" {
+		if clean {
+			repackage(targetDir, repackageTo)
+		} else {
+			log.Infof("Run with --clean to write %v without the duplicates", repackageTo)
+		}
+	}
+	reportSkippedFiles(viper.GetBool("show-skipped"))
+	if changelog := viper.GetString("changelog"); changelog != "" {
+		writeChangelog(changelog, clean, jars, keepJars)
+	}
+	if reviewQueue := viper.GetString("review-queue"); reviewQueue != "" {
+		updateReviewQueue(reviewQueue, jars)
+	}
+	export(exporters, ExportRun{
+		Report:  buildReport(targetDir, mode, clean, jars, keepJars, viper.GetString("group-by")),
+		Jars:    jars,
+		Project: projectName(targetDir, projectConfig),
+	})
+	return summarize(jars), count, clean
+}
+
+// analyzeJars decides which JARs to keep, runs the checks and guards files
+// from removal, and returns the JARs cleaning applies to, restricted to the
+// --module ones.
+func analyzeJars(targetDir string, projectConfig string, mode string, policy Policy, filePaths []string, jars []JarProperties) ([]JarProperties, map[string]JarProperties) {
+	keepJars := decideJarsToKeep(jars, mode, policy)
+	if !viper.GetBool("ignore-renamed-copies") {
+		jars = append(jars, renamedCopies(filePaths, jars)...)
+	}
+	checkSymlinks(jars)
+	runChecks(jars, keepJars)
+	checkCaseCollisions(targetDir, filePaths, keepJars)
+	if viper.GetBool("nested") {
+		checkNestedJars(jars, keepJars, mode)
+	}
+	checkOwners(jars, keepJars, policy)
+	checkRepository(keepJars)
+	if viper.GetBool("guard-dependencies") {
+		guardSoleProviders(jars, keepJars)
+	}
+	guardLowConfidence(jars, keepJars, policy.minConfidence)
+	if viper.GetBool("check-class-overlap") {
+		checkClassOverlap(jars, keepJars)
+	}
+	if viper.GetBool("check-split-packages") {
+		checkSplitPackages(jars, keepJars)
+	}
+	if mxbuildLog := viper.GetString("import-mxbuild-log"); mxbuildLog != "" {
+		reconcileMxbuildLog(mxbuildLog, jars)
+	}
+	if managed := managedDependencies(targetDir, projectConfig); managed != "" {
+		reconcileManagedDependencies(managed, jars)
+	}
+	return restrictToModules(jars, viper.GetStringSlice("module")), keepJars
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "  %s [command] [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s%s\n", command.name, command.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	pflag.PrintDefaults()
+}
+
+func decideJarsToKeep(jars []JarProperties, mode string, policy Policy) map[string]JarProperties {
+	regularModes := []string{"auto", "strict"}
 	keepJars := make(map[string]JarProperties)
+	jars, companions := splitCompanions(jars)
 
 	if contains(regularModes, mode) {
 		log.Infof("Mode: %v", mode)
 		keepJars = computeJarsToKeep(jars)
+		applyPackagingPreference(jars, keepJars)
 	} else {
 		log.Infof("Mode: m2ee-log at %v", mode)
 		keepJars = computeJarsToKeepFromM2eeLog(jars, mode)
 	}
-	count := cleanJars(clean, filePaths, jars, keepJars)
-
-	if clean {
-		log.Infof("Total files removed: %d", count)
-	} else {
-		log.Infof("Would have removed: %d files", count)
-		log.Infof("Use --clean to actually remove above file(s)")
+	if policy.contentHash {
+		applyContentHashes(keepJars)
+	}
+	if policy.allowList != nil {
+		applyAllowList(jars, keepJars, rangesByIdentity(jars, policy.allowList))
+	}
+	if policy.vulnerabilities != nil {
+		checkVulnerabilities(jars, keepJars, policy.vulnerabilities)
+	}
+	if policy.constraints != nil {
+		applyConstraints(jars, keepJars, rangesByIdentity(jars, policy.constraints))
 	}
+	applyModuleRequirements(jars, keepJars)
+	applyKeepOverrides(jars, keepJars, policy.keepOverrides)
+	applyFragmentHosts(jars, keepJars)
+	applyCompanions(jars, companions, keepJars)
+	return keepJars
+}
 
+// runChecks reports problems that remain with the JARs to keep.
+func runChecks(jars []JarProperties, keepJars map[string]JarProperties) {
+	checkModuleKinds(jars, keepJars)
+	checkNativePlatforms(jars, keepJars)
+	checkVariants(jars, keepJars)
+	checkServiceProviders(jars, keepJars)
+	checkOverlappingResources(jars, keepJars)
+	checkClassShadowing(jars, keepJars)
+	checkJavaVersion(jars, keepJars)
 }
 
 func listAllFiles(targetDir string) []string {
-	log.Infof("Listing all files in target directory: %v", targetDir)
-	files, err := ioutil.ReadDir(targetDir)
+	if source := viper.GetString("files"); source != "" {
+		return filterFiles(targetDir, listGivenFiles(targetDir, source))
+	}
+	log.Infof("Listing all files in target directory: %v", reportPath(targetDir))
+	maxDepth := 0
+	if viper.GetBool("recursive") || isExtractedPackage(targetDir) {
+		maxDepth = viper.GetInt("max-depth")
+		if maxDepth <= 0 {
+			maxDepth = math.MaxInt32
+		}
+	}
+	return filterFiles(targetDir, listFiles(targetDir, 0, maxDepth, nil, make(map[string]bool)))
+}
+
+// listFiles lists the files in dir and, up to maxDepth levels deep, in its
+// subdirectories. The .cleanerignore of each directory applies to it and its
+// subdirectories. Directories reached again through symlinks are listed once.
+func listFiles(dir string, depth int, maxDepth int, scopes []ignoreScope, visited map[string]bool) []string {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if visited[real] {
+			skipFile(dir, "listed already")
+			return nil
+		}
+		visited[real] = true
+	}
+	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
+		if depth == 0 {
+			fatal(err)
+		}
+		log.Warningf("Unable to list %v: %v", dir, err)
+		skipFile(dir, fmt.Sprintf("unreadable: %v", err))
+		return nil
+	}
+	if rules := loadIgnoreRules(dir); len(rules) > 0 {
+		scopes = append(scopes[:len(scopes):len(scopes)], ignoreScope{dir: dir, rules: rules})
 	}
 	filePaths := []string{}
 	for _, f := range files {
-		if !f.IsDir() {
-			filePath := filepath.Join(targetDir, f.Name())
-			filePaths = append(filePaths, filePath)
+		filePath := filepath.Join(dir, f.Name())
+		if isSymlink(f) {
+			info, ok := resolveSymlink(filePath)
+			if !ok {
+				continue
+			}
+			f = info
+		}
+		if isIgnoredIn(scopes, filePath, f.IsDir()) {
+			skipFile(filePath, "matches "+ignoreFileName)
+			continue
+		}
+		if f.IsDir() {
+			if depth < maxDepth {
+				filePaths = append(filePaths, listFiles(filePath, depth+1, maxDepth, scopes, visited)...)
+			} else {
+				skipFile(filePath, "directory")
+			}
+			continue
 		}
+		filePaths = append(filePaths, filePath)
 	}
 	return filePaths
 }
 
+// relativePath returns the path of a file relative to the target, with
+// forward slashes, e.g. legacy/foo-1.0.jar. It is the file name for files
+// directly in the target.
+func relativePath(targetDir string, filePath string) string {
+	rel, err := filepath.Rel(targetDir, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
+
 func listAllJars(filePaths []string, mode string) []JarProperties {
 	log.Info("Finding and parsing JARs")
-	jars := []JarProperties{}
+	jarPaths := []string{}
 	for _, f := range filePaths {
-		if strings.HasSuffix(f, ".jar") {
-			log.Debugf("Processing JAR: %v", f)
-			jarProp := getJarProps(f, mode)
-			if strings.Compare(jarProp.filePath, "") != 0 {
-				jars = append(jars, jarProp)
+		if !isArchive(f) {
+			skipFile(f, "not a JAR")
+			continue
+		}
+		jarPaths = append(jarPaths, f)
+	}
+
+	// results are stored by index to keep the order independent of --jobs
+	results := make([]JarProperties, len(jarPaths))
+	jobs := viper.GetInt("jobs")
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				logger := newJarLog()
+				results[index] = getJarProps(jarPaths[index], mode, logger)
+				logger.flush()
 			}
+		}()
+	}
+	for index := range jarPaths {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	jars := []JarProperties{}
+	for _, jarProp := range results {
+		if strings.Compare(jarProp.filePath, "") != 0 {
+			jars = append(jars, jarProp)
 		}
 	}
+	attributeModules(filePaths, jars)
+	logCaseVariants(jars)
 	return jars
 }
 
-func getJarProps(filePath string, mode string) JarProperties {
+func getJarProps(filePath string, mode string, logger jarLog) JarProperties {
+	logger.Debugf("Processing JAR: %v", filePath)
 
-	archive, err := zip.OpenReader(filePath)
+	archive, err := openJar(filePath)
+	if pointer, ok := readLFSPointer(filePath); err != nil && ok {
+		logger.Warningf("%v is a Git LFS pointer, not a JAR", filepath.Base(filePath))
+		skipFile(filePath, "Git LFS pointer")
+		addFinding(lfsPointerFinding(filePath, pointer))
+		return JarProperties{}
+	}
 	if err != nil {
-		panic(err)
+		skipFile(filePath, fmt.Sprintf("unreadable: %v", err))
+		addFinding(Finding{Type: "corrupt", Severity: severityCritical, Message: fmt.Sprintf("%v cannot be opened: %v", filepath.Base(filePath), err),
+			Files: []string{filePath}, SuggestedFix: &SuggestedFix{Action: "replace-file", Target: filePath, Detail: "download the JAR again"}})
+		return JarProperties{}
 	}
 	defer archive.Close()
 
+	jarProp := identifyJar(archive.Reader, filePath, mode, logger)
+	jarProp.setFileDetails(jarProp.fileName)
+	inspectEntries(&jarProp, archive.Reader, logger)
+	if jarProp.filePath != "" {
+		applyEquivalences(&jarProp, logger)
+		applyAliases(&jarProp)
+		jarProp.shaded = shadedLibraries(archive.Reader)
+		excludeShaded(&jarProp, logger)
+		excludeResourceOnly(&jarProp, archive.Reader, logger)
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		jarProp.size = info.Size()
+	}
+	if size, ok := remoteSize(filePath); ok {
+		jarProp.size = size
+	}
+	return jarProp
+}
+
+func identifyJar(archive *zip.Reader, filePath string, mode string, logger jarLog) JarProperties {
+	var pomXML, moduleInfo *zip.File
 	for _, f := range archive.File {
 		fileName := filepath.Base(f.Name)
 
-		if !(strings.Compare(f.Name, "META-INF/MANIFEST.MF") == 0 || strings.Compare(fileName, "pom.properties") == 0) {
+		if f.Name == "module-info.class" || (moduleInfo == nil && versionedModuleInfo.MatchString(f.Name)) {
+			moduleInfo = f
 			continue
 		}
-		//log.Println("unzipping file ", fileName)
 
-		file, err := ioutil.TempFile("", "jar")
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer os.Remove(file.Name())
-
-		dstFile, err := os.OpenFile(file.Name(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fileInArchive, err := f.Open()
-		if err != nil {
-			log.Fatal(err)
+		if pomXML == nil && isPOMXML(f.Name) {
+			pomXML = f
+			continue
 		}
 
-		if _, err := io.Copy(dstFile, fileInArchive); err != nil {
-			log.Fatal(err)
+		if isGradleModule(f.Name) {
+			jar4 := parseGradleModule(filePath, string(extractEntry(f)))
+			if jar4.key() != "" {
+				logger.Debugf("Parsed properties from Gradle module metadata: %v", jar4)
+				jar4.confidence = confidenceHigh
+				return jar4
+			}
+			continue
 		}
-
-		dstFile.Close()
-		fileInArchive.Close()
-
-		b, err := ioutil.ReadFile(file.Name())
-		if err != nil {
-			log.Warningf("Unable to read file: %v", err)
+		if !(strings.Compare(f.Name, "META-INF/MANIFEST.MF") == 0 || strings.Compare(fileName, "pom.properties") == 0) {
+			continue
 		}
 
 		// try manifest first
-		text := string(b)
+		text := string(extractEntry(f))
 		jar1 := parseManifest(filePath, text)
-		if jar1.packageName != "" {
-			log.Debugf("Parsed properties from MANIFEST: %v", jar1)
+		if jar1.key() != "" {
+			logger.Debugf("Parsed properties from MANIFEST: %v", jar1)
+			jar1.confidence = confidenceHigh
 			return jar1
 		}
 		jar2 := parsePOM(filePath, text)
-		if jar2.packageName != "" {
-			log.Debugf("Parsed properties from POM: %v", jar2)
+		if jar2.key() != "" {
+			if hasPlaceholder(jar2.version) {
+				jar2.version = resolvePOMVersion(archive, f.Name, jar2, logger)
+			}
+			logger.Debugf("Parsed properties from POM: %v", jar2)
+			jar2.confidence = confidenceHigh
 			return jar2
 		}
 	}
 
+	// only used without a manifest or pom.properties identifying the JAR
+	if pomXML != nil {
+		jar5 := parsePOMXML(filePath, extractEntry(pomXML))
+		if jar5.key() != "" {
+			logger.Debugf("Parsed properties from pom.xml: %v", jar5)
+			jar5.confidence = confidenceHigh
+			return jar5
+		}
+	}
+	if moduleInfo != nil {
+		jar6 := parseModuleInfoJar(filePath, extractEntry(moduleInfo), logger)
+		if jar6.key() != "" {
+			logger.Debugf("Parsed properties from module-info.class: %v", jar6)
+			jar6.confidence = confidenceMedium
+			return jar6
+		}
+	}
+
+	jar7 := identifyByFingerprint(filePath, logger)
+	if jar7.key() != "" {
+		logger.Debugf("Identified by fingerprint: %v", jar7)
+		jar7.confidence = confidenceHigh
+		return jar7
+	}
+
+	jar9 := identifyByTriage(filePath, logger)
+	if jar9.key() != "" {
+		logger.Debugf("Identified by triage: %v", jar9)
+		jar9.confidence = confidenceHigh
+		return jar9
+	}
+
 	if mode == "auto" {
-		jar3 := parseOptimistic(filePath)
-		if jar3.packageName != "" {
-			log.Debugf("Parsed properties optimistically: %v", jar3)
+		jar3 := parseOptimistic(filePath, logger)
+		if jar3.key() != "" {
+			logger.Debugf("Parsed properties optimistically: %v", jar3)
 			return jar3
 		}
 	}
 
-	log.Warningf("Failed to parse metadata from %v", filePath)
+	jar8 := parseCompanionFileName(filePath)
+	if jar8.key() != "" {
+		logger.Debugf("Parsed properties of companion from file name: %v", jar8)
+		jar8.confidence = confidenceLow
+		return jar8
+	}
+
+	if !hasClasses(archive) {
+		// not a library to identify, see excludeResourceOnly
+		logger.Debugf("No metadata in %v, which contains no classes", filePath)
+		return JarProperties{Coordinates: Coordinates{artifact: filePath, unique: filePath}, filePath: filePath, fileName: filepath.Base(filePath), confidence: confidenceLowest}
+	}
 
-	return JarProperties{filePath: filePath, packageName: filePath, fileName: filepath.Base(filePath), version: ""}
+	logger.Warningf("Failed to parse metadata from %v", filePath)
+	addFinding(Finding{Type: "unidentified", Severity: severityWarning, Package: filePath,
+		Message: fmt.Sprintf("Failed to parse metadata from %v", filepath.Base(filePath)), Files: []string{filePath},
+		SuggestedFix: &SuggestedFix{Action: "review-file", Target: filePath, Detail: "identify the library and replace it with a JAR containing metadata"}})
+
+	return JarProperties{Coordinates: Coordinates{artifact: filePath, unique: filePath}, filePath: filePath, fileName: filepath.Base(filePath), confidence: confidenceLowest}
 }
 
-func parseManifest(filePath string, text string) JarProperties {
-	lines := strings.Split(text, "\n")
-	jarProp := JarProperties{filePath: filePath, packageName: "", fileName: filepath.Base(filePath), version: ""}
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		pair := strings.Split(line, ": ")
+// extractEntry extracts a file from the archive into the temporary directory
+// and returns its content.
+func extractEntry(f *zip.File) []byte {
+	file, err := ioutil.TempFile(tempDir(), "jar")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.Remove(file.Name())
 
-		if len(pair) < 2 {
+	dstFile, err := os.OpenFile(file.Name(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		fatal(err)
+	}
+
+	fileInArchive, err := f.Open()
+	if err != nil {
+		fatal(err)
+	}
+
+	if _, err := io.Copy(dstFile, fileInArchive); err != nil {
+		fatal(err)
+	}
+
+	dstFile.Close()
+	fileInArchive.Close()
+	file.Close()
+
+	b, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		log.Warningf("Unable to read file: %v", err)
+	}
+	return b
+}
+
+func parseManifest(filePath string, text string) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	names := make(map[string]string)
+	for _, attribute := range parseManifestAttributes(text) {
+		key := attribute.name
+		value := attribute.value
+		if (key == "Bundle-Name" || key == "Implementation-Title") && value == "Apache POI" {
+			// skip this because it's a false positive
 			continue
 		}
-
-		key := pair[0]
-		value := pair[1]
-		// Automatic-Module-Name - used in org.apache.httpcomponents.httpclient / org.apache.httpcomponents.client5.httpclient5
-		if key == "Bundle-SymbolicName" || key == "Extension-Name" || key == "Automatic-Module-Name" {
-			jarProp.packageName = value
+		// attribute names are case-insensitive
+		if _, ok := names[strings.ToLower(key)]; !ok && value != "" {
+			names[strings.ToLower(key)] = value
+		}
+		if key == "Bundle-SymbolicName" && jarProp.bundle == "" {
+			// directives like ;singleton:=true are not part of the name
+			jarProp.bundle = strings.TrimSpace(strings.SplitN(value, ";", 2)[0])
+			names[strings.ToLower(key)] = jarProp.bundle
 		} else if key == "Bundle-Version" || key == "Implementation-Version" {
-			jarProp.version = value
-			jarProp.versionNumber = convertVersionToNumber(jarProp.version)
+			// the main section comes first, entry sections can carry the
+			// versions of the packages they describe
+			if jarProp.version == "" {
+				jarProp.version = value
+			}
 		} else if key == "Bundle-Vendor" || key == "Implementation-Vendor" {
-			jarProp.vendor = value
+			if jarProp.vendor == "" {
+				jarProp.vendor = value
+			}
 		} else if key == "Bundle-License" {
-			jarProp.license = value
-		} else if key == "Bundle-Name" || key == "Implementation-Title" {
-			if value == "Apache POI" {
-				// skip this because it's a false positive
-				continue
+			if jarProp.license == "" {
+				jarProp.license = value
 			}
-			jarProp.name = value
-			if jarProp.packageName == "" {
-				// only use Bundle-Name as packageName if no alternative exists
-				jarProp.packageName = value
+		} else if key == "Bundle-Name" || key == "Implementation-Title" {
+			if jarProp.name == "" {
+				jarProp.name = value
 			}
 		}
 	}
+	packageName := ""
+	for _, attribute := range nameAttributes {
+		if value := names[strings.ToLower(attribute)]; value != "" {
+			packageName = value
+			break
+		}
+	}
+	jarProp.setName(packageName)
 	return jarProp
 }
 
 func parsePOM(filePath string, text string) JarProperties {
-	lines := strings.Split(text, "\n")
-	jarProp := JarProperties{filePath: filePath, packageName: "", fileName: filepath.Base(filePath)}
-	groupId := ""
-	artifactId := ""
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		pair := strings.Split(line, "=")
-		if pair[0] == "groupId" {
-			groupId = pair[1]
-		} else if pair[0] == "artifactId" {
-			artifactId = pair[1]
-		} else if pair[0] == "version" {
-			jarProp.version = pair[1]
-			jarProp.versionNumber = convertVersionToNumber(jarProp.version)
-		}
-	}
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	properties := parseProperties([]byte(text))
+	groupId := resolvePlaceholders(strings.TrimSpace(properties["groupId"]), properties)
+	artifactId := resolvePlaceholders(strings.TrimSpace(properties["artifactId"]), properties)
+	jarProp.version = resolvePlaceholders(strings.TrimSpace(properties["version"]), properties)
 	if groupId != "" && artifactId != "" {
-		jarProp.packageName = groupId + "." + artifactId
+		jarProp.group = groupId
+		jarProp.artifact = artifactId
 	}
 	return jarProp
 }
 
-func parseOptimistic(filePath string) JarProperties {
-	// filePath = junit-4.11.jar
-	jarProp := JarProperties{filePath: filePath, packageName: "", fileName: filepath.Base(filePath)}
+// maxPackageDepth is the number of package segments the inferred identity
+// of a JAR has at most, e.g. org.example.hello.there.
+const maxPackageDepth = 4
+
+// maxAlternatives is the number of other packages recorded next to the
+// inferred identity.
+const maxAlternatives = 5
+
+// dominantPackage infers the identity of a JAR from its classes: the package,
+// cut to maxPackageDepth segments, that has the most classes, and the other
+// packages by class count.
+func dominantPackage(archive *zip.Reader) (string, []string) {
+	counts := make(map[string]int)
+	for _, f := range archive.File {
+		// skips META-INF, where multi-release JARs repeat their classes
+		if classNameFromEntry(f.Name) == "" {
+			continue
+		}
+		dirs := strings.Split(path.Dir(f.Name), "/")
+		if dirs[0] == "." {
+			// the default package
+			continue
+		}
+		if len(dirs) > maxPackageDepth {
+			// eg. org/example/hello/there/deeper/MyClass.class
+			dirs = dirs[:maxPackageDepth]
+		}
+		counts[strings.Join(dirs, ".")]++
+	}
+	packageNames := []string{}
+	for packageName := range counts {
+		packageNames = append(packageNames, packageName)
+	}
+	if len(packageNames) == 0 {
+		return "", nil
+	}
+	sort.Slice(packageNames, func(i, j int) bool {
+		if counts[packageNames[i]] != counts[packageNames[j]] {
+			return counts[packageNames[i]] > counts[packageNames[j]]
+		}
+		return packageNames[i] < packageNames[j]
+	})
+	alternatives := packageNames[1:]
+	if len(alternatives) > maxAlternatives {
+		alternatives = alternatives[:maxAlternatives]
+	}
+	return packageNames[0], alternatives
+}
 
-	// version
-	tokens := strings.Split(filePath, "-")
+// versionFromFileName takes the last dash separated part of the file name
+// as the version. Packaging variants like junit-4.11-shaded.jar have the
+// same version as junit-4.11.jar.
+func versionFromFileName(filePath string) string {
+	base := trimArchiveExtension(filepath.Base(filePath))
+	for _, variant := range packagingVariants {
+		base = strings.TrimSuffix(base, "-"+variant)
+	}
+	tokens := strings.Split(base, "-")
 	if len(tokens) > 1 {
-		jarProp.version = strings.Replace(tokens[len(tokens)-1], ".jar", "", 1)
-		jarProp.versionNumber = convertVersionToNumber(jarProp.version)
+		return tokens[len(tokens)-1]
+	}
+	return ""
+}
+
+func parseOptimistic(filePath string, logger jarLog) JarProperties {
+	// filePath = junit-4.11.jar
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+
+	artifact, version, classifier, matched := matchFileName(jarProp.fileName)
+	if matched {
+		logger.Debugf("%v matches a --filename-pattern: artifact %q, version %q, classifier %q", jarProp.fileName, artifact, version, classifier)
+		jarProp.version, jarProp.classifier = version, classifier
+	} else {
+		jarProp.version = versionFromFileName(filePath)
+	}
+	if artifact != "" {
+		// the pattern names the library, the classes are not needed
+		jarProp.setName(artifact)
+		jarProp.confidence = confidenceLow
+		return jarProp
 	}
+	jarProp.confidence = confidenceLowest
 
-	archive, err := zip.OpenReader(filePath)
+	archive, err := openJar(filePath)
 	if err != nil {
-		panic(err)
+		logger.Warningf("Unable to open %v: %v", filePath, err)
+		return jarProp
 	}
 	defer archive.Close()
-	re := regexp.MustCompile(`(org|com)/.*\.class$`)
-
-	for _, f := range archive.File {
-		if match := re.MatchString(f.Name); match {
-			tokens = strings.Split(f.Name, "/")
-			if len(tokens) > 4 {
-				// eg. org/example/hello/there/MyClass.class
-				tokens = tokens[:4]
-			} else if len(tokens) > 3 {
-				// eg. org/example/hello/MyClass.class
-				tokens = tokens[:3]
-			} else if len(tokens) > 2 {
-				// eg. org/example/MyClass.class
-				tokens = tokens[:2]
-			} else {
-				tokens = tokens[:1]
-			}
-			jarProp.packageName = strings.Join(tokens, ".")
-			break
+	packageName, alternatives := dominantPackage(archive.Reader)
+	if packageName != "" {
+		jarProp.setName(packageName)
+		jarProp.alternatives = alternatives
+		if len(alternatives) > 0 {
+			logger.Debugf("Inferred %v for %v, alternatives: %v", packageName, jarProp.fileName, strings.Join(alternatives, ", "))
 		}
 	}
 	return jarProp
@@ -310,8 +1006,8 @@ func computeJarsToKeepFromM2eeLog(jars []JarProperties, m2eeLog string) map[stri
 			continue
 		}
 
-		if _, ok := keepJars[jar1.packageName]; !ok {
-			keepJars[jar1.packageName] = jar1
+		if _, ok := keepJars[jar1.key()]; !ok {
+			keepJars[jar1.key()] = jar1
 		}
 	}
 	return keepJars
@@ -351,13 +1047,14 @@ func getJarFileNames(m2eeLog string) []string {
 func computeJarsToKeep(jars []JarProperties) map[string]JarProperties {
 	log.Info("Computing duplicates")
 	var keepJars = make(map[string]JarProperties)
+	logAliases(jars)
 
 	for _, jar1 := range jars {
 		//log.Println("Checking " + jar1.filePath)
-		if _, ok := keepJars[jar1.packageName]; !ok {
-			keepJars[jar1.packageName] = jar1
+		if _, ok := keepJars[jar1.key()]; !ok {
+			keepJars[jar1.key()] = jar1
 		}
-		packageName := jar1.packageName
+		packageName := jar1.key()
 
 		// find latest
 		for _, jar2 := range jars {
@@ -370,13 +1067,15 @@ func computeJarsToKeep(jars []JarProperties) map[string]JarProperties {
 				// skip self
 				continue
 			}
-			if strings.Compare(packageName, jar2.packageName) == 0 {
-				goodFileSuffix := fmt.Sprintf("%s%s", jar2.version, ".jar")
-				if latestJar.versionNumber == jar2.versionNumber && strings.HasSuffix(jar2.filePath, goodFileSuffix) {
+			if strings.Compare(packageName, jar2.key()) == 0 {
+				goodFileSuffix := fmt.Sprintf("%s%s", jar2.version, archiveExtension(jar2.fileName))
+				order := compareVersions(latestJar.version, jar2.version)
+				if order == 0 && strings.HasSuffix(jar2.filePath, goodFileSuffix) {
 					log.Infof("Preferring file %v over %v", jar2.fileName, latestJar.fileName)
 					keepJars[packageName] = jar2
-				} else if latestJar.versionNumber < jar2.versionNumber {
+				} else if order < 0 {
 					log.Infof("Found newer %v over %v", jar2.fileName, latestJar.fileName)
+					log.Debugf("Version order: %v", versionOrder(jar2.version, latestJar.version))
 					keepJars[packageName] = jar2
 				}
 			}
@@ -385,28 +1084,125 @@ func computeJarsToKeep(jars []JarProperties) map[string]JarProperties {
 	return keepJars
 }
 
+func parseKeepOverrides(values []string) map[string]string {
+	overrides := make(map[string]string)
+	for _, value := range values {
+		pair := strings.SplitN(value, "=", 2)
+		if len(pair) < 2 || pair[0] == "" || pair[1] == "" {
+			fatalf("Invalid --keep value %q, expected package=version", value)
+		}
+		overrides[normalizeKey(pair[0])] = pair[1]
+	}
+	return overrides
+}
+
+func applyKeepOverrides(jars []JarProperties, keepJars map[string]JarProperties, overrides map[string]string) {
+	packageNames := []string{}
+	for packageName := range overrides {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		version := overrides[packageName]
+		found := false
+		var kept JarProperties
+		for _, jar := range jars {
+			if jar.fullKey() != packageName || jar.version != version {
+				continue
+			}
+			if !found || strings.HasSuffix(trimArchiveExtension(jar.fileName), version) {
+				kept = jar
+			}
+			found = true
+		}
+		if found {
+			keepJars[kept.key()] = kept
+			log.Infof("Keeping %v as requested by --keep %v=%v", kept.fileName, packageName, version)
+		} else {
+			log.Warningf("No JAR found for --keep %v=%v", packageName, version)
+		}
+	}
+}
+
+// staysAfterCleaning tells whether cleaning leaves a JAR in place: the kept
+// JARs, guarded and held duplicates, and those of critical packages, which
+// are only removed after confirming each file.
+func staysAfterCleaning(jar JarProperties, keepJars map[string]JarProperties, holds map[string]string) bool {
+	jarToKeep, ok := keepJars[jar.key()]
+	if jar.filePath == jarToKeep.filePath {
+		return true
+	}
+	if _, guarded := guardReason(jar.filePath); guarded {
+		return true
+	}
+	if _, held := holds[jar.fullKey()]; held && ok {
+		return true
+	}
+	return isCriticalPackage(jar.fullKey())
+}
+
 func cleanJars(remove bool, filePaths []string, jars []JarProperties, keepJars map[string]JarProperties) int {
 	log.Info("Cleaning...")
 	jarsCount := 0
 	metafilesCount := 0
+	holds := activeHolds(time.Now())
 	for _, jar := range jars {
-		jarToKeep := keepJars[jar.packageName]
+		jarToKeep, ok := keepJars[jar.key()]
 		if strings.Compare(jar.filePath, jarToKeep.filePath) != 0 {
-			for _, filePath := range filePaths {
-				if _, err := os.Stat(filePath); err == nil {
-					if strings.HasPrefix(filePath, jar.filePath) {
-						if remove {
-							log.Warningf("Removing file %v: %v", jar.packageName, filePath)
-							os.Remove(filePath)
-						} else {
-							log.Warningf("Would remove file %v: %v", jar.packageName, filePath)
-						}
-						if strings.HasSuffix(filePath, ".jar") {
-							jarsCount++
-						} else {
-							metafilesCount++
-						}
+			if reason, ok := guardReason(jar.filePath); ok {
+				log.Debugf("Keeping %v, the %v", jar.fileName, reason)
+				continue
+			}
+			if until, held := holds[jar.fullKey()]; held && ok {
+				log.Warningf("Holding %v next to kept %v until %v", jar.fileName, jarToKeep.fileName, until)
+				addFinding(Finding{Type: "held", Severity: severityInfo, Package: jar.key(),
+					Message: fmt.Sprintf("%v is a duplicate of kept %v, held until %v", jar.fileName, jarToKeep.fileName, until),
+					Files:   []string{jar.filePath},
+					SuggestedFix: &SuggestedFix{Action: "review-file", Target: jar.filePath,
+						Detail: "remove after the hold ends on " + until}})
+				continue
+			}
+			critical := isCriticalPackage(jar.fullKey())
+			if critical {
+				addFinding(criticalPackageFinding(jar, jarToKeep))
+			} else if ok {
+				message := fmt.Sprintf("%v is a duplicate of kept %v", jar.fileName, jarToKeep.fileName)
+				if jar.relocation != "" {
+					message += "; " + jar.relocation
+				} else if jarToKeep.relocation != "" {
+					message += "; " + jarToKeep.relocation
+				}
+				if jar.aliasOf != "" || jarToKeep.aliasOf != "" {
+					message += "; grouped by alias as " + jar.key()
+				}
+				addFinding(Finding{Type: "duplicate", Severity: severityInfo, Package: jar.key(),
+					Message: message, Files: []string{jar.filePath}, SuggestedFix: removeFileFix(jar.filePath)})
+			}
+			for _, filePath := range jarFiles(jar, filePaths) {
+				if err := checkMutation(filePath); err != nil {
+					log.Errorf("Refusing to remove %v: %v", filePath, err)
+					continue
+				}
+				if remove && critical && !confirmRemoval(filePath) {
+					log.Warningf("Keeping file of critical package %v: %v", jar.key(), reportPath(filePath))
+					continue
+				}
+				if remove {
+					log.Warningf("Removing file %v: %v", jar.key(), reportPath(filePath))
+					if err := disposeFile(filePath); err != nil {
+						log.Errorf("Unable to remove %v: %v", filePath, err)
+						continue
 					}
+				} else if critical {
+					log.Warningf("Would ask to remove file of critical package %v: %v", jar.key(), reportPath(filePath))
+				} else {
+					log.Warningf("Would remove file %v: %v", jar.key(), reportPath(filePath))
+				}
+				if isArchive(filePath) {
+					jarsCount++
+				} else {
+					metafilesCount++
 				}
 			}
 		} else {
@@ -417,19 +1213,14 @@ func cleanJars(remove bool, filePaths []string, jars []JarProperties, keepJars m
 	return jarsCount + metafilesCount
 }
 
-func convertVersionToNumber(version string) int {
-	// naive implementation. Feel free to suggest improvements
-
-	re := regexp.MustCompile("[0-9]+")
-
-	multiplier := 1000
-	number := 0
-	for _, c := range re.FindAllString(version, -1) {
-		t, _ := strconv.Atoi(c)
-		if number > 0 {
-			number = number * multiplier
+// jarFiles returns the JAR and its companion files, like .meta and
+// .RequiredLib files, that still exist.
+func jarFiles(jar JarProperties, filePaths []string) []string {
+	files := []string{}
+	for _, filePath := range filePaths {
+		if _, err := os.Stat(filePath); err == nil && strings.HasPrefix(filePath, jar.filePath) {
+			files = append(files, filePath)
 		}
-		number += t
 	}
-	return number
+	return files
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// managedDependencyFiles are where Gradle records resolved dependencies, for
+// Mendix 10 managed dependencies downloaded to vendorlib, relative to the
+// project directory.
+var managedDependencyFiles = []string{"vendorlib/gradle.lockfile", "gradle.lockfile", "gradle/verification-metadata.xml"}
+
+type managedDependency struct {
+	group    string
+	artifact string
+	version  string
+}
+
+func (d managedDependency) String() string {
+	return d.group + ":" + d.artifact + ":" + d.version
+}
+
+// managedDependencies returns --managed-dependencies, or else the first
+// lockfile or verification metadata in the project, or an empty string.
+func managedDependencies(targetDir string, projectConfig string) string {
+	if path := viper.GetString("managed-dependencies"); path != "" {
+		return path
+	}
+	dir := projectDir(targetDir, projectConfig)
+	for _, name := range managedDependencyFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadManagedDependencies reads a Gradle lockfile, lines like
+// com.google.guava:guava:31.1-jre=runtimeClasspath, or the components of a
+// gradle/verification-metadata.xml.
+func loadManagedDependencies(path string) []managedDependency {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read managed dependencies: %v", err)
+	}
+	dependencies := []managedDependency{}
+	if strings.HasSuffix(path, ".xml") {
+		metadata := struct {
+			Components []struct {
+				Group   string `xml:"group,attr"`
+				Name    string `xml:"name,attr"`
+				Version string `xml:"version,attr"`
+			} `xml:"components>component"`
+		}{}
+		if err := xml.Unmarshal(b, &metadata); err != nil {
+			fatalf("Unable to parse %v: %v", path, err)
+		}
+		for _, component := range metadata.Components {
+			dependencies = append(dependencies, managedDependency{component.Group, component.Name, component.Version})
+		}
+	} else {
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(strings.SplitN(line, "=", 2)[0], ":")
+			if len(fields) != 3 {
+				// e.g. empty=annotationProcessor
+				continue
+			}
+			dependencies = append(dependencies, managedDependency{fields[0], fields[1], fields[2]})
+		}
+	}
+	log.Infof("Loaded %d managed dependencies from %v", len(dependencies), path)
+	return dependencies
+}
+
+// isManaged tells whether a userlib JAR is the managed dependency: by its
+// group and artifact, or by a Maven style file name like guava-31.1-jre.jar
+// for JARs identified by their manifest, whose key differs. The manifest name
+// must then be within the group, like org.apache.commons.lang3 is for
+// org.apache.commons:commons-lang3, so artifacts of the same name from other
+// groups do not match.
+func isManaged(jar JarProperties, dependency managedDependency) bool {
+	managed := Coordinates{group: dependency.group, artifact: dependency.artifact}
+	if jar.fullKey() == managed.fullKey() {
+		return true
+	}
+	rest := strings.TrimPrefix(jar.fileName, dependency.artifact+"-")
+	if rest == jar.fileName || rest == "" || !isDigit(rest[0]) {
+		return false
+	}
+	group := normalizeKey(dependency.group)
+	return jar.group == "" || strings.HasPrefix(jar.fullKey()+".", group+".") || strings.HasPrefix(group+".", jar.fullKey()+".")
+}
+
+// reconcileManagedDependencies reports userlib JARs that Gradle manages as
+// well: as managed-duplicate when the versions are equal, which is safe to
+// remove from userlib, and as managed-conflict when they differ.
+func reconcileManagedDependencies(path string, jars []JarProperties) {
+	dependencies := loadManagedDependencies(path)
+	sort.Slice(dependencies, func(i, j int) bool { return dependencies[i].String() < dependencies[j].String() })
+	for _, jar := range jars {
+		if isUnidentified(jar) {
+			continue
+		}
+		for _, dependency := range dependencies {
+			if !isManaged(jar, dependency) {
+				continue
+			}
+			fix := &SuggestedFix{Action: "review-file", Target: jar.filePath, Detail: "remove it from userlib, the managed dependency provides it"}
+			if compareVersions(jar.version, dependency.version) != 0 {
+				fix.Detail = "remove it from userlib or align the version of the managed dependency"
+			}
+			if compareVersions(jar.version, dependency.version) == 0 {
+				log.Infof("%v is also a managed dependency: %v", jar.fileName, dependency)
+				addFinding(Finding{Type: "managed-duplicate", Severity: severityInfo, Package: jar.key(),
+					Message: fmt.Sprintf("%v is also the managed dependency %v", jar.fileName, dependency),
+					Files:   []string{jar.filePath}, SuggestedFix: fix})
+			} else {
+				log.Warningf("%v conflicts with managed dependency %v", jar.fileName, dependency)
+				addFinding(Finding{Type: "managed-conflict", Severity: severityWarning, Package: jar.key(),
+					Message: fmt.Sprintf("%v has version %v, the managed dependency is %v", jar.fileName, jar.version, dependency),
+					Files:   []string{jar.filePath}, SuggestedFix: fix})
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestIsManaged(t *testing.T) {
+	lang3 := managedDependency{"org.apache.commons", "commons-lang3", "3.12.0"}
+	utils := managedDependency{"org.example", "utils", "1.0"}
+	tests := []struct {
+		name       string
+		jar        JarProperties
+		dependency managedDependency
+		want       bool
+	}{
+		{"same coordinates", JarProperties{fileName: "commons-lang3-3.12.0.jar",
+			Coordinates: Coordinates{group: "org.apache.commons", artifact: "commons-lang3"}}, lang3, true},
+		{"manifest name within the group", JarProperties{fileName: "commons-lang3-3.12.0.jar",
+			Coordinates: Coordinates{group: "org.apache.commons", artifact: "lang3"}}, lang3, true},
+		{"no group", JarProperties{fileName: "utils-1.0.jar",
+			Coordinates: Coordinates{artifact: "Utilities"}}, utils, true},
+		{"same artifact of another group", JarProperties{fileName: "utils-1.0.jar",
+			Coordinates: Coordinates{group: "com.vendor", artifact: "utils"}}, utils, false},
+		{"file name of another artifact", JarProperties{fileName: "commons-lang3-extras-1.0.jar",
+			Coordinates: Coordinates{group: "org.apache.commons", artifact: "extras"}}, lang3, false},
+	}
+	for _, test := range tests {
+		if got := isManaged(test.jar, test.dependency); got != test.want {
+			t.Errorf("%v: isManaged(%v, %v) = %v, want %v", test.name, test.jar.fileName, test.dependency, got, test.want)
+		}
+	}
+}
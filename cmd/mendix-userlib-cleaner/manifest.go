@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+)
+
+// nameAttributes are the manifest attributes naming a JAR, in the order of
+// precedence set with --manifest-attributes.
+var nameAttributes = defaultNameAttributes
+
+// Automatic-Module-Name - used in org.apache.httpcomponents.httpclient / org.apache.httpcomponents.client5.httpclient5
+var defaultNameAttributes = []string{"Bundle-SymbolicName", "Extension-Name", "Automatic-Module-Name", "Implementation-Title", "Bundle-Name"}
+
+func setNameAttributes(values []string) {
+	if len(values) == 0 {
+		fatal("--manifest-attributes needs at least one attribute")
+	}
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" || strings.Contains(value, ":") {
+			fatalf("Invalid --manifest-attributes value %q, expected an attribute name like Bundle-SymbolicName", value)
+		}
+	}
+	nameAttributes = values
+}
+
+type manifestAttribute struct {
+	name  string
+	value string
+}
+
+// parseManifestAttributes returns the attributes of a JAR manifest in their
+// order. Lines are at most 72 bytes, longer values continue on the next
+// lines, which start with a single space. The main section comes first, the
+// sections of individual entries follow after empty lines; their attributes
+// are included since some JARs only describe their packages there.
+func parseManifestAttributes(text string) []manifestAttribute {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	// unfold continuation lines
+	text = strings.ReplaceAll(text, "\n ", "")
+
+	attributes := []manifestAttribute{}
+	for _, line := range strings.Split(text, "\n") {
+		pair := strings.SplitN(line, ":", 2)
+		if len(pair) < 2 || pair[0] == "Name" {
+			// the Name of a section is an entry path, not the name of the JAR
+			continue
+		}
+		attributes = append(attributes, manifestAttribute{strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])})
+	}
+	return attributes
+}
+
+// manifestValue returns the value of an attribute of a manifest, the one of
+// the main section if several sections have it.
+func manifestValue(text string, key string) string {
+	for _, attribute := range parseManifestAttributes(text) {
+		if attribute.name == key {
+			return attribute.value
+		}
+	}
+	return ""
+}
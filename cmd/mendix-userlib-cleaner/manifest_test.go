@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManifestAttributes(t *testing.T) {
+	text := "Manifest-Version: 1.0\r\n" +
+		"Bundle-SymbolicName: org.apache.commons.commons-lang3;singleton:=true\r\n" +
+		"Export-Package: org.apache.commons.lang3;version=\"3.12.0\",org.apache.co\r\n" +
+		" mmons.lang3.builder;version=\"3.12.0\"\r\n" +
+		"Implementation-Title: Apache Commons\r\n" +
+		"  Lang\r\n" +
+		"\r\n" +
+		"Name: org/apache/commons/lang3/\r\n" +
+		"Specification-Title: lang3\r\n"
+	want := []manifestAttribute{
+		{"Manifest-Version", "1.0"},
+		{"Bundle-SymbolicName", "org.apache.commons.commons-lang3;singleton:=true"},
+		{"Export-Package", "org.apache.commons.lang3;version=\"3.12.0\",org.apache.commons.lang3.builder;version=\"3.12.0\""},
+		{"Implementation-Title", "Apache Commons Lang"},
+		{"Specification-Title", "lang3"},
+	}
+	if got := parseManifestAttributes(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseManifestAttributes() = %q, want %q", got, want)
+	}
+}
+
+func TestManifestValue(t *testing.T) {
+	tests := []struct {
+		text, key, want string
+	}{
+		{"Bundle-Name: a\nBundle-Version: 1.0\n", "Bundle-Version", "1.0"},
+		{"Bundle-Version: 1.0.0.20\n 240101\n", "Bundle-Version", "1.0.0.20240101"},
+		{"Bundle-Version: 1.0\r\rName: a/b\rBundle-Version: 2.0\r", "Bundle-Version", "1.0"},
+		{"Name: a/b\nBundle-Version: 2.0\n", "Name", ""},
+		{"Bundle-Name: a\n", "Bundle-Version", ""},
+	}
+	for _, test := range tests {
+		if got := manifestValue(test.text, test.key); got != test.want {
+			t.Errorf("manifestValue(%q, %q) = %q, want %q", test.text, test.key, got, test.want)
+		}
+	}
+}
+
+func TestParseManifestPrefersMainSection(t *testing.T) {
+	text := "Manifest-Version: 1.0\n" +
+		"Implementation-Title: jaxb-runtime\n" +
+		"Implementation-Version: 2.3.3\n" +
+		"Implementation-Vendor: Eclipse Foundation\n" +
+		"Bundle-SymbolicName: org.glassfish.jaxb.runtime\n" +
+		"\n" +
+		"Name: com/sun/istack/\n" +
+		"Implementation-Title: istack-commons-runtime\n" +
+		"Implementation-Version: 3.0.11\n" +
+		"Implementation-Vendor: Oracle Corporation\n"
+	jar := parseManifest("userlib/jaxb-runtime-2.3.3.jar", text)
+	if jar.version != "2.3.3" || jar.name != "jaxb-runtime" || jar.vendor != "Eclipse Foundation" {
+		t.Errorf("parseManifest() = version %q, name %q, vendor %q, want the main section's 2.3.3, jaxb-runtime, Eclipse Foundation", jar.version, jar.name, jar.vendor)
+	}
+	if jar.bundle != "org.glassfish.jaxb.runtime" {
+		t.Errorf("parseManifest() bundle = %q, want org.glassfish.jaxb.runtime", jar.bundle)
+	}
+}
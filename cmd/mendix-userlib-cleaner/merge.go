@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CombinedReport consolidates the reports of many projects or runs.
+type CombinedReport struct {
+	Totals  ReportTotals `json:"totals"`
+	Reports []Report     `json:"reports"`
+}
+
+type ReportTotals struct {
+	Reports  int            `json:"reports"`
+	Jars     int            `json:"jars"`
+	Size     int64          `json:"size"`
+	Findings map[string]int `json:"findings"`
+}
+
+// mergeReports combines JSON reports into one, written as JSON, HTML or CSV
+// depending on the extension of outputPath.
+func mergeReports(reportPaths []string, outputPath string) {
+	if len(reportPaths) == 0 {
+		fatal("Nothing to merge, pass the JSON reports as arguments")
+	}
+	if outputPath == "" {
+		fatal("Use --report to set the path of the combined report")
+	}
+
+	combined := CombinedReport{Totals: ReportTotals{Findings: make(map[string]int)}, Reports: []Report{}}
+	for _, reportPath := range reportPaths {
+		b, err := ioutil.ReadFile(reportPath)
+		if err != nil {
+			fatalf("Unable to read report: %v", err)
+		}
+		report := Report{}
+		if err := json.Unmarshal(b, &report); err != nil {
+			fatalf("Unable to parse report %v: %v", reportPath, err)
+		}
+		combined.Reports = append(combined.Reports, report)
+		combined.Totals.Reports++
+		combined.Totals.Jars += len(report.Jars)
+		for _, jar := range report.Jars {
+			combined.Totals.Size += jar.Size
+		}
+		for _, finding := range report.Findings {
+			combined.Totals.Findings[finding.Severity]++
+		}
+		log.Debugf("Merged %v: %d JARs, %d findings", reportPath, len(report.Jars), len(report.Findings))
+	}
+
+	var data []byte
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".html", ".htm":
+		data = renderHTMLReport(combined)
+	case ".csv":
+		data = renderCSVReport(combined)
+	default:
+		b, err := json.MarshalIndent(combined, "", "  ")
+		if err != nil {
+			fatal(err)
+		}
+		data = b
+	}
+	if err := writeFileAtomic(outputPath, data, 0644); err != nil {
+		fatalf("Unable to write report: %v", err)
+	}
+	log.Infof("Merged %d reports with %d JARs into %v", combined.Totals.Reports, combined.Totals.Jars, outputPath)
+}
+
+// renderCSVReport writes one row per JAR, with the types of the findings
+// mentioning it.
+func renderCSVReport(combined CombinedReport) []byte {
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	writer.Write([]string{"target", "file", "package", "version", "size", "kept", "findings", "rules"})
+	for _, report := range combined.Reports {
+		for _, jar := range report.Jars {
+			types := []string{}
+			ruleIDs := []string{}
+			for _, finding := range report.Findings {
+				if contains(finding.Files, jar.FilePath) && !contains(types, finding.Type) {
+					types = append(types, finding.Type)
+					if finding.Rule != "" {
+						ruleIDs = append(ruleIDs, finding.Rule)
+					}
+				}
+			}
+			writer.Write([]string{report.Target, jar.FileName, jar.Package, jar.Version, strconv.FormatInt(jar.Size, 10), strconv.FormatBool(jar.Kept), strings.Join(types, " "), strings.Join(ruleIDs, " ")})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{"bytes": formatBytes}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Userlib report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; }
+.critical { color: #b00; } .warning { color: #b60; }
+</style>
+</head>
+<body>
+<h1>Userlib report</h1>
+<p>{{.Totals.Reports}} report(s), {{.Totals.Jars}} JAR(s), {{bytes .Totals.Size}},
+{{index .Totals.Findings "critical"}} critical, {{index .Totals.Findings "warning"}} warning(s), {{index .Totals.Findings "info"}} info</p>
+{{range .Reports}}
+<h2>{{.Target}}</h2>
+<table>
+<tr><th>File</th><th>Package</th><th>Version</th><th>Size</th><th>Kept</th></tr>
+{{range .Jars}}<tr><td>{{.FileName}}</td><td>{{.Package}}</td><td>{{.Version}}</td><td>{{bytes .Size}}</td><td>{{if .Kept}}yes{{else}}no{{end}}</td></tr>
+{{end}}</table>
+{{if .Findings}}<table>
+<tr><th>Severity</th><th>Rule</th><th>Type</th><th>Message</th></tr>
+{{range .Findings}}<tr class="{{.Severity}}"><td>{{.Severity}}</td><td>{{.Rule}}</td><td>{{.Type}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+func renderHTMLReport(combined CombinedReport) []byte {
+	buffer := new(bytes.Buffer)
+	if err := htmlReport.Execute(buffer, combined); err != nil {
+		fatal(err)
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+var metricsHeader = []string{"date", "project", "jars", "duplicates", "vulnerable", "bytes_reclaimed"}
+
+// appendMetrics adds a row with the summary of this run to a CSV file, so
+// runs can be charted over time. The header is written when the file is new.
+// Duplicates include those of critical packages, bytes_reclaimed counts the
+// files actually removed or quarantined.
+func appendMetrics(path string, project string, jars []JarProperties) error {
+	_, err := os.Stat(path)
+	newFile := os.IsNotExist(err)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	summary := summarize(jars)
+	duplicates := 0
+	for _, finding := range allFindings() {
+		switch finding.Type {
+		case "duplicate", "identical-content", "critical-package":
+			duplicates++
+		}
+	}
+	writer := csv.NewWriter(file)
+	if newFile {
+		writer.Write(metricsHeader)
+	}
+	writer.Write([]string{
+		time.Now().Format(time.RFC3339),
+		project,
+		strconv.Itoa(len(jars)),
+		strconv.Itoa(duplicates),
+		strconv.Itoa(summary.vulnerable),
+		strconv.FormatInt(disposedBytes, 10),
+	})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	log.Infof("Metrics appended to %v", path)
+	return nil
+}
+
+// projectName names the project in the metrics.
+func projectName(targetDir string, projectConfig string) string {
+	return filepath.Base(projectDir(targetDir, projectConfig))
+}
+
+// projectDir returns the directory containing .mendix if there is one, else
+// the one with the .mpr file or else the directory containing userlib.
+func projectDir(targetDir string, projectConfig string) string {
+	if projectConfig != "" {
+		return filepath.Dir(filepath.Dir(projectConfig))
+	}
+	if root := findProjectRoot(targetDir); root != "" {
+		return root
+	}
+	dir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return targetDir
+	}
+	if filepath.Base(dir) == "userlib" {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
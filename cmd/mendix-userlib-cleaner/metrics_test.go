@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMetricsCountDuplicatesAndDisposedBytes(t *testing.T) {
+	defer viper.Reset()
+	defer resetTarget()
+	target := t.TempDir()
+	copyFixtureJars(t, target, "checker-qual-2.5.2.jar", "junit-4.11.jar")
+	resetTarget()
+	guardTarget(target)
+	removed := filepath.Join(target, "checker-qual-2.5.2.jar")
+	info, err := os.Stat(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := disposeFile(removed); err != nil {
+		t.Fatal(err)
+	}
+	addFinding(Finding{Type: "duplicate", Files: []string{removed}})
+	addFinding(Finding{Type: "identical-content", Files: []string{removed}})
+	// declined removals of critical packages are duplicates but reclaim nothing
+	addFinding(Finding{Type: "critical-package", Files: []string{filepath.Join(target, "junit-4.11.jar")}})
+
+	path := filepath.Join(t.TempDir(), "metrics.csv")
+	if err := appendMetrics(path, "MyApp", []JarProperties{{filePath: removed}, {filePath: filepath.Join(target, "junit-4.11.jar")}}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || !reflect.DeepEqual(rows[0], metricsHeader) {
+		t.Fatalf("metrics = %q, want the header and one row", rows)
+	}
+	want := []string{"MyApp", "2", "3", "0", strconv.FormatInt(info.Size(), 10)}
+	if got := rows[1][1:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics row = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// parseModuleInfoJar identifies a JAR by the name of its Java module, like
+// org.slf4j. The version is compiled into few modules, else it is taken
+// from the file name.
+func parseModuleInfoJar(filePath string, content []byte, logger jarLog) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	name, version, err := parseModuleInfo(content)
+	if err != nil {
+		logger.Debugf("Unable to read module-info.class of %v: %v", filePath, err)
+		return jarProp
+	}
+	if version == "" {
+		version = versionFromFileName(filePath)
+	}
+	jarProp.setName(name)
+	jarProp.version = version
+	jarProp.moduleName = name
+	return jarProp
+}
+
+// parseModuleInfo reads the module name and version, if compiled in, from a
+// module-info.class.
+func parseModuleInfo(b []byte) (string, string, error) {
+	r := classReader{b: b}
+	if r.u4() != 0xCAFEBABE {
+		return "", "", errors.New("not a class file")
+	}
+	r.skip(4) // minor and major version
+
+	// constant pool, indexes start at 1
+	count := int(r.u2())
+	utf8 := make(map[int]string)
+	modules := make(map[int]int)
+	for i := 1; i < count && r.err == nil; i++ {
+		switch tag := r.u1(); tag {
+		case 1:
+			utf8[i] = string(r.bytes(int(r.u2())))
+		case 19:
+			modules[i] = int(r.u2())
+		case 7, 8, 16, 20:
+			r.skip(2)
+		case 15:
+			r.skip(3)
+		case 3, 4, 9, 10, 11, 12, 17, 18:
+			r.skip(4)
+		case 5, 6:
+			// longs and doubles take two entries
+			r.skip(8)
+			i++
+		default:
+			return "", "", fmt.Errorf("unknown constant pool tag %d", tag)
+		}
+	}
+
+	r.skip(6) // access flags, this and super class
+	r.skip(2 * int(r.u2()))
+	for _, members := range []string{"fields", "methods"} {
+		n := int(r.u2())
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skip(6)
+			for j := int(r.u2()); j > 0 && r.err == nil; j-- {
+				r.skip(2)
+				r.skip(int(r.u4()))
+			}
+		}
+		if r.err != nil {
+			return "", "", fmt.Errorf("truncated %v", members)
+		}
+	}
+	for n := int(r.u2()); n > 0 && r.err == nil; n-- {
+		name := utf8[int(r.u2())]
+		length := int(r.u4())
+		if name != "Module" {
+			r.skip(length)
+			continue
+		}
+		attribute := classReader{b: r.bytes(length)}
+		moduleName := utf8[modules[int(attribute.u2())]]
+		attribute.skip(2) // flags
+		version := utf8[int(attribute.u2())]
+		if attribute.err != nil || moduleName == "" {
+			return "", "", errors.New("invalid Module attribute")
+		}
+		return moduleName, version, nil
+	}
+	if r.err != nil {
+		return "", "", r.err
+	}
+	return "", "", errors.New("no Module attribute")
+}
+
+// classReader reads the big-endian values of a class file. After reading
+// past the end, err is set and zeros are returned.
+type classReader struct {
+	b   []byte
+	err error
+}
+
+func (r *classReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = errors.New("unexpected end of class file")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *classReader) skip(n int) { r.bytes(n) }
+
+func (r *classReader) u1() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *classReader) u2() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *classReader) u4() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+// moduleInfoClass compiles the module-info.class of a module with no
+// dependencies, and with a version unless it is empty.
+func moduleInfoClass(name string, version string) []byte {
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 53}
+	u2 := func(v int) { b = append(b, byte(v>>8), byte(v)) }
+	utf8 := func(s string) {
+		b = append(b, 1)
+		u2(len(s))
+		b = append(b, s...)
+	}
+	// #1 Module, #2 name, #3 module #2, #4 version, #5 a long taking #5 and #6
+	u2(7)
+	utf8("Module")
+	utf8(name)
+	b = append(b, 19)
+	u2(2)
+	utf8(version)
+	b = append(b, 5, 0, 0, 0, 0, 0, 0, 0, 1)
+	u2(0x8000) // ACC_MODULE
+	u2(0)      // this class
+	u2(0)      // super class
+	u2(0)      // interfaces
+	u2(0)      // fields
+	u2(0)      // methods
+	u2(1)      // attributes
+	u2(1)
+	b = append(b, 0, 0, 0, 6)
+	u2(3)
+	u2(0)
+	if version == "" {
+		u2(0)
+	} else {
+		u2(4)
+	}
+	return b
+}
+
+func TestParseModuleInfo(t *testing.T) {
+	name, version, err := parseModuleInfo(moduleInfoClass("org.slf4j", "2.0.9"))
+	if err != nil || name != "org.slf4j" || version != "2.0.9" {
+		t.Errorf("parseModuleInfo() = %q, %q, %v, want org.slf4j, 2.0.9", name, version, err)
+	}
+	name, version, err = parseModuleInfo(moduleInfoClass("org.slf4j", ""))
+	if err != nil || name != "org.slf4j" || version != "" {
+		t.Errorf("parseModuleInfo() without version = %q, %q, %v, want org.slf4j", name, version, err)
+	}
+}
+
+func TestParseModuleInfoInvalid(t *testing.T) {
+	class := moduleInfoClass("org.slf4j", "2.0.9")
+	for _, b := range [][]byte{nil, []byte("not a class"), class[:len(class)-3], class[:20]} {
+		if name, _, err := parseModuleInfo(b); err == nil {
+			t.Errorf("parseModuleInfo(%x) = %q, want an error", b, name)
+		}
+	}
+	unknownTag := append([]byte{}, class...)
+	unknownTag[10] = 99
+	if _, _, err := parseModuleInfo(unknownTag); err == nil {
+		t.Error("parseModuleInfo() with an unknown constant pool tag succeeded")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var jarFileNamePattern = regexp.MustCompile(`[\w.+~-]+\.jar\b`)
+
+// parseMxbuildLog returns the sets of JAR file names mxbuild or Studio Pro
+// warned about as duplicates, one set per warning, e.g.
+//
+//	WARNING: The userlib directory contains duplicate libraries: commons-io-2.4.jar, commons-io-2.11.0.jar
+func parseMxbuildLog(path string) [][]string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read mxbuild log: %v", err)
+	}
+
+	duplicates := [][]string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if !strings.Contains(strings.ToLower(line), "duplicate") {
+			continue
+		}
+		fileNames := []string{}
+		for _, name := range jarFileNamePattern.FindAllString(line, -1) {
+			if !contains(fileNames, filepath.Base(name)) {
+				fileNames = append(fileNames, filepath.Base(name))
+			}
+		}
+		if len(fileNames) > 0 {
+			sort.Strings(fileNames)
+			duplicates = append(duplicates, fileNames)
+		}
+	}
+	log.Infof("Found %d duplicate warning(s) in %v", len(duplicates), path)
+	return duplicates
+}
+
+// reconcileMxbuildLog compares the duplicates mxbuild warned about with the
+// ones found here. Warnings this tool does not agree with, and duplicates
+// mxbuild did not mention, are reported as mxbuild-discrepancy.
+func reconcileMxbuildLog(path string, jars []JarProperties) {
+	byFileName := make(map[string]JarProperties)
+	copies := make(map[string][]string)
+	for _, jar := range jars {
+		byFileName[jar.fileName] = jar
+		copies[jar.key()] = append(copies[jar.key()], jar.fileName)
+	}
+
+	confirmed := make(map[string]bool)
+	agreed := 0
+	for _, fileNames := range parseMxbuildLog(path) {
+		packageNames := []string{}
+		files := []string{}
+		for _, fileName := range fileNames {
+			jar, ok := byFileName[fileName]
+			if !ok {
+				log.Debugf("mxbuild mentions %v, which is not in the target", fileName)
+				continue
+			}
+			files = append(files, jar.filePath)
+			if !contains(packageNames, jar.key()) {
+				packageNames = append(packageNames, jar.key())
+			}
+		}
+		switch {
+		case len(files) == 0:
+			continue
+		case len(packageNames) == 1 && len(copies[packageNames[0]]) > 1:
+			confirmed[packageNames[0]] = true
+			agreed++
+		default:
+			log.Warningf("mxbuild reports %v as duplicates, they are identified as %v", strings.Join(fileNames, ", "), strings.Join(packageNames, ", "))
+			addFinding(Finding{Type: "mxbuild-discrepancy", Severity: severityWarning, Package: strings.Join(packageNames, " "),
+				Message:      fmt.Sprintf("mxbuild reports %v as duplicates, but they are identified as %v", strings.Join(fileNames, ", "), strings.Join(packageNames, ", ")),
+				Files:        files,
+				SuggestedFix: &SuggestedFix{Action: "review-file", Target: strings.Join(fileNames, " "), Detail: "check the metadata of these JARs, e.g. with --verbose"}})
+		}
+	}
+
+	packageNames := []string{}
+	for packageName := range copies {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+	for _, packageName := range packageNames {
+		if len(copies[packageName]) < 2 || confirmed[packageName] {
+			continue
+		}
+		log.Infof("mxbuild does not report the duplicates of %v: %v", packageName, strings.Join(copies[packageName], ", "))
+		addFinding(Finding{Type: "mxbuild-discrepancy", Severity: severityInfo, Package: packageName,
+			Message: fmt.Sprintf("%v are duplicates of %v not reported by mxbuild", strings.Join(copies[packageName], ", "), packageName)})
+	}
+	log.Infof("mxbuild agrees on %d duplicate warning(s)", agreed)
+}
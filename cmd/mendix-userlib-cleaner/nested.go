@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// nestedSeparator joins the path of a JAR and an entry inside it, as in
+// jar:file:wrapper.jar!/lib/foo.jar URLs.
+const nestedSeparator = "!/"
+
+// listNestedJars identifies the JARs a wrapper JAR embeds, usually under
+// lib/, BOOT-INF/lib/ or WEB-INF/lib/. Their file path is the path of the
+// wrapper and the entry, e.g. wrapper.jar!/lib/foo-1.0.jar.
+func listNestedJars(jar JarProperties, mode string) []JarProperties {
+	archive, err := openJar(jar.filePath)
+	if err != nil {
+		log.Warningf("Unable to open %v: %v", jar.fileName, err)
+		return nil
+	}
+	defer archive.Close()
+
+	nested := []JarProperties{}
+	for _, f := range archive.File {
+		if !strings.HasSuffix(f.Name, ".jar") || f.FileInfo().IsDir() {
+			continue
+		}
+		// nested JARs are identified from a temporary copy under their own name
+		dir, err := ioutil.TempDir(tempDir(), "nested")
+		if err != nil {
+			fatal(err)
+		}
+		tmpPath := filepath.Join(dir, path.Base(f.Name))
+		if err := ioutil.WriteFile(tmpPath, extractEntry(f), 0644); err != nil {
+			fatal(err)
+		}
+		// the log and findings about the temporary copy, like unidentified,
+		// are not actionable
+		nestedJar := identifyNestedJar(tmpPath, mode, newJarLog())
+		os.RemoveAll(dir)
+		retainFindings(func(finding Finding) bool { return !contains(finding.Files, tmpPath) })
+		if nestedJar.filePath == "" {
+			continue
+		}
+		unidentified := isUnidentified(nestedJar)
+		nestedJar.filePath = jar.filePath + nestedSeparator + f.Name
+		nestedJar.fileName = path.Base(f.Name)
+		if unidentified {
+			nestedJar.artifact = nestedJar.filePath
+		}
+		log.Debugf("%v is nested in %v: %v %v", nestedJar.fileName, jar.fileName, nestedJar.key(), nestedJar.version)
+		nested = append(nested, nestedJar)
+	}
+	return nested
+}
+
+func identifyNestedJar(filePath string, mode string, logger jarLog) JarProperties {
+	archive, err := openJar(filePath)
+	if err != nil {
+		logger.Warningf("Unable to open nested %v: %v", filepath.Base(filePath), err)
+		return JarProperties{}
+	}
+	defer archive.Close()
+	jarProp := identifyJar(archive.Reader, filePath, mode, logger)
+	jarProp.setFileDetails(jarProp.fileName)
+	applyEquivalences(&jarProp, logger)
+	applyAliases(&jarProp)
+	return jarProp
+}
+
+// checkNestedJars lists the JARs nested in the JARs of userlib and warns
+// about nested libraries that are also in userlib as a JAR of their own, so
+// two copies, maybe of different versions, end up on the classpath.
+func checkNestedJars(jars []JarProperties, keepJars map[string]JarProperties, mode string) {
+	log.Info("Looking for nested JARs")
+	for i := range jars {
+		if strings.Contains(jars[i].filePath, nestedSeparator) || jars[i].copyOf != "" {
+			continue
+		}
+		jars[i].nested = listNestedJars(jars[i], mode)
+		for _, nested := range jars[i].nested {
+			standalone, ok := keepJars[nested.key()]
+			if !ok || isUnidentified(nested) || standalone.filePath == jars[i].filePath {
+				continue
+			}
+			log.Warningf("%v nested in %v is also in userlib as %v", nested.fileName, jars[i].fileName, standalone.fileName)
+			addFinding(Finding{Type: "nested-duplicate", Severity: severityWarning, Package: nested.key(),
+				Message: fmt.Sprintf("%v (%v %v) is nested in %v and also in userlib as %v (%v)", nested.fileName, nested.key(), nested.version, jars[i].fileName, standalone.fileName, standalone.version),
+				Files:   []string{jars[i].filePath, standalone.filePath},
+				SuggestedFix: &SuggestedFix{Action: "review-file", Target: standalone.filePath,
+					Detail: "remove " + standalone.fileName + " if " + jars[i].fileName + " provides it, or use a build of " + jars[i].fileName + " without nested libraries"}})
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"io/ioutil"
+	"strconv"
+	"syscall"
+)
+
+// setNiceness lowers the scheduling priority of the process. On Linux every
+// thread has its own niceness, so all existing threads are changed and new
+// ones inherit it.
+func setNiceness(niceness int) error {
+	if tasks, err := ioutil.ReadDir("/proc/self/task"); err == nil {
+		for _, task := range tasks {
+			if tid, err := strconv.Atoi(task.Name()); err == nil {
+				if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, niceness); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, niceness)
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"syscall"
+)
+
+const (
+	belowNormalPriorityClass = 0x00004000
+	idlePriorityClass        = 0x00000040
+)
+
+var setPriorityClass = syscall.NewLazyDLL("kernel32.dll").NewProc("SetPriorityClass")
+
+// setNiceness maps the niceness to a priority class: below normal up to 9,
+// idle from 10.
+func setNiceness(niceness int) error {
+	class := belowNormalPriorityClass
+	if niceness >= 10 {
+		class = idlePriorityClass
+	}
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return err
+	}
+	if ok, _, err := setPriorityClass.Call(uintptr(process), uintptr(class)); ok == 0 {
+		return err
+	}
+	return nil
+}
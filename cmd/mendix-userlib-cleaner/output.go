@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/op/go-logging"
+)
+
+// outputMutex serializes everything written to stderr, so that concurrent
+// scans never interleave within a line or within the messages of one JAR.
+var outputMutex sync.Mutex
+
+type serializedWriter struct {
+	writer io.Writer
+}
+
+func (w serializedWriter) Write(p []byte) (int, error) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return w.writer.Write(p)
+}
+
+var stderr = serializedWriter{os.Stderr}
+
+// jarLog buffers the log messages of a single JAR scan. The messages are
+// formatted when they are logged and written out in one piece by flush.
+type jarLog struct {
+	*logging.Logger
+	buffer *bytes.Buffer
+}
+
+func newJarLog() jarLog {
+	buffer := new(bytes.Buffer)
+	backend := logging.AddModuleLevel(logging.NewBackendFormatter(logging.NewLogBackend(buffer, "", 0), format))
+	backend.SetLevel(logging.GetLevel("main"), "main")
+	logger := logging.MustGetLogger("main")
+	logger.SetBackend(backend)
+	return jarLog{logger, buffer}
+}
+
+func (l jarLog) flush() {
+	if l.buffer.Len() > 0 {
+		stderr.Write(l.buffer.Bytes())
+		l.buffer.Reset()
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// overlapExamples is the number of shared classes named in a class-overlap
+// finding.
+const overlapExamples = 3
+
+// retainedJars returns the JARs that stay after cleaning: the kept ones and
+// those guarded from removal.
+func retainedJars(jars []JarProperties, keepJars map[string]JarProperties) []JarProperties {
+	retained := []JarProperties{}
+	for _, jar := range jars {
+		if _, ok := guardReason(jar.filePath); ok || keepJars[jar.key()].filePath == jar.filePath {
+			retained = append(retained, jar)
+		}
+	}
+	return retained
+}
+
+// checkClassOverlap reports retained JARs of different libraries that
+// contain the same classes, like a fat JAR next to a library it bundles or a
+// library repackaged under other coordinates. Only one copy of each class is
+// loaded, by classpath order, so the other JAR may run against classes of
+// another version.
+func checkClassOverlap(jars []JarProperties, keepJars map[string]JarProperties) {
+	log.Info("Checking for classes provided by several libraries")
+	retained := retainedJars(jars, keepJars)
+	index := indexClasses(retained)
+
+	type overlap struct {
+		jars    [2]JarProperties
+		classes []string
+	}
+	overlaps := make(map[string]*overlap)
+	for className, providers := range index {
+		for i := 0; i < len(providers); i++ {
+			for j := i + 1; j < len(providers); j++ {
+				a, b := providers[i], providers[j]
+				if a.key() == b.key() {
+					continue
+				}
+				if a.filePath > b.filePath {
+					a, b = b, a
+				}
+				pair := a.filePath + "\x00" + b.filePath
+				if overlaps[pair] == nil {
+					overlaps[pair] = &overlap{jars: [2]JarProperties{a, b}}
+				}
+				overlaps[pair].classes = append(overlaps[pair].classes, className)
+			}
+		}
+	}
+
+	pairs := []string{}
+	for pair := range overlaps {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	for _, pair := range pairs {
+		o := overlaps[pair]
+		sort.Strings(o.classes)
+		examples := o.classes
+		if len(examples) > overlapExamples {
+			examples = examples[:overlapExamples]
+		}
+		a, b := o.jars[0], o.jars[1]
+		log.Warningf("%v and %v share %d class(es), e.g. %v", a.fileName, b.fileName, len(o.classes), strings.Join(examples, ", "))
+		addFinding(Finding{Type: "class-overlap", Severity: severityWarning, Package: a.key(),
+			Message: fmt.Sprintf("%v (%v) and %v (%v) both contain %d class(es), e.g. %v", a.fileName, a.key(), b.fileName, b.key(), len(o.classes), strings.Join(examples, ", ")),
+			Files:   []string{a.filePath, b.filePath},
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: b.filePath,
+				Detail: "keep only one of " + a.fileName + " and " + b.fileName + ", or an alias if they are the same library"}})
+	}
+}
+
+// checkSplitPackages reports Java packages whose classes are spread over
+// several retained JARs. OSGi resolves a package from one bundle only, and on
+// the classpath classes of the package in both JARs shadow each other.
+func checkSplitPackages(jars []JarProperties, keepJars map[string]JarProperties) {
+	log.Info("Checking for packages split over several JARs")
+	index := indexClasses(retainedJars(jars, keepJars))
+
+	// classes per package and JAR, and those in more than one JAR
+	classes := make(map[string]map[string]int)
+	shared := make(map[string]int)
+	providers := make(map[string]map[string]JarProperties)
+	for className, providing := range index {
+		packageName := javaPackage(className)
+		if packageName == "" {
+			continue
+		}
+		if classes[packageName] == nil {
+			classes[packageName] = make(map[string]int)
+			providers[packageName] = make(map[string]JarProperties)
+		}
+		for _, jar := range providing {
+			classes[packageName][jar.filePath]++
+			providers[packageName][jar.filePath] = jar
+		}
+		if len(providing) > 1 {
+			shared[packageName]++
+		}
+	}
+
+	packageNames := []string{}
+	for packageName := range providers {
+		if len(providers[packageName]) > 1 {
+			packageNames = append(packageNames, packageName)
+		}
+	}
+	sort.Strings(packageNames)
+	for _, packageName := range packageNames {
+		filePaths := []string{}
+		for filePath := range providers[packageName] {
+			filePaths = append(filePaths, filePath)
+		}
+		sort.Strings(filePaths)
+		counts := []string{}
+		for _, filePath := range filePaths {
+			counts = append(counts, fmt.Sprintf("%d in %v", classes[packageName][filePath], providers[packageName][filePath].fileName))
+		}
+		log.Warningf("Package %v is split: %v, %d class(es) in more than one", packageName, strings.Join(counts, ", "), shared[packageName])
+		addFinding(Finding{Type: "split-package", Severity: severityWarning, Package: packageName,
+			Message: fmt.Sprintf("Package %v is split over %d JARs, with class(es) %v, %d of them in more than one JAR", packageName, len(filePaths), strings.Join(counts, ", "), shared[packageName]),
+			Files:   filePaths,
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: packageName,
+				Detail: "keep the package in a single JAR, it breaks OSGi resolution and may shadow classes"}})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// ownersFileName is the sidecar file next to userlib that is used without
+// --owners.
+const ownersFileName = "userlib.owners.yaml"
+
+// Owner tells who is accountable for a JAR and why it is needed.
+type Owner struct {
+	Owner         string `yaml:"owner"`
+	Justification string `yaml:"justification"`
+}
+
+// ownersFile returns --owners, or else userlib.owners.yaml in the project
+// directory if it exists.
+func ownersFile() string {
+	if path := viper.GetString("owners"); path != "" {
+		return path
+	}
+	targetDir := viper.GetString("target")
+	projectConfig := ""
+	if !viper.GetBool("ignore-project-config") {
+		projectConfig = findProjectConfig(targetDir)
+	}
+	path := filepath.Join(projectDir(targetDir, projectConfig), ownersFileName)
+	if fileExists(path) {
+		return path
+	}
+	return ""
+}
+
+// loadOwners reads a YAML file mapping packages or JAR file names to their
+// owner, e.g.
+//
+//	org.apache.pdfbox.pdfbox:
+//	  owner: team-documents
+//	  justification: PDF export in the Invoices module
+func loadOwners(path string) map[string]Owner {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read owners: %v", err)
+	}
+	entries := make(map[string]Owner)
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		fatalf("Unable to parse owners %v: %v", path, err)
+	}
+	owners := make(map[string]Owner)
+	for name, owner := range entries {
+		if !isArchive(name) {
+			name = normalizeKey(name)
+		}
+		owners[name] = owner
+	}
+	log.Infof("Loaded %d owner(s) from %v", len(owners), path)
+	return owners
+}
+
+// checkOwners fills in the owners of the JARs, by file name or else by
+// package. With --require-owners, kept JARs that no Marketplace module
+// requires need an owner and a justification.
+func checkOwners(jars []JarProperties, keepJars map[string]JarProperties, policy Policy) {
+	for i := range jars {
+		owner, ok := policy.owners[jars[i].fileName]
+		if !ok {
+			owner = policy.owners[jars[i].key()]
+		}
+		jars[i].owner, jars[i].justification = owner.Owner, owner.Justification
+		if !policy.requireOwners || keepJars[jars[i].key()].filePath != jars[i].filePath || len(jars[i].modules) > 0 || (owner.Owner != "" && owner.Justification != "") {
+			continue
+		}
+		log.Warningf("No owner and justification for %v", jars[i].fileName)
+		addFinding(Finding{Type: "unowned", Severity: severityWarning, Package: jars[i].key(),
+			Message: fmt.Sprintf("%v is not required by a Marketplace module and has no owner and justification", jars[i].fileName),
+			Files:   []string{jars[i].filePath},
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: jars[i].filePath,
+				Detail: "add its owner and justification to " + ownersFileName}})
+	}
+}
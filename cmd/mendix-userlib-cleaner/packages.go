@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// packageExtensions are Mendix deployment archives and module exports, ZIP
+// files with the JARs of the app or module in a lib folder, like
+// model/lib/userlib in a .mda and userlib in a .mpk.
+var packageExtensions = []string{".mda", ".mpk"}
+
+// packageLibDirs are the folders of a package holding JARs, at any depth.
+var packageLibDirs = []string{"userlib", "vendorlib", "lib"}
+
+// extractedPackages maps the temporary directory a package is extracted to,
+// to the package.
+var extractedPackages = make(map[string]string)
+
+// packageEntries are the entries extracted from each package, by directory.
+var packageEntries = make(map[string][]string)
+
+func isPackage(filePath string) bool {
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return false
+	}
+	return contains(packageExtensions, strings.ToLower(filepath.Ext(filePath)))
+}
+
+// extractPackages replaces targets that are packages by a temporary
+// directory with the JARs in their lib folders, keeping their paths, and
+// remote targets by a mirror.
+func extractPackages(targets []string) []string {
+	extracted := make([]string, len(targets))
+	for i, targetDir := range targets {
+		extracted[i] = targetDir
+		if isRemote(targetDir) {
+			extracted[i] = mirrorRemote(targetDir)
+		} else if isPackage(targetDir) {
+			extracted[i] = extractPackage(targetDir)
+		}
+	}
+	return extracted
+}
+
+func extractPackage(filePath string) string {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		fatalf("Unable to open %v: %v", filePath, err)
+	}
+	defer archive.Close()
+
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	dir, err := ioutil.TempDir(tempDir(), "package")
+	if err != nil {
+		fatal(err)
+	}
+	dir = filepath.Join(dir, name)
+	count := 0
+	for _, f := range archive.File {
+		entry := path.Clean(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(entry, "../") || path.IsAbs(entry) || !inLibDir(entry, packageLibDirs) {
+			continue
+		}
+		if !isArchive(entry) && !strings.HasSuffix(entry, ".meta") && !strings.HasSuffix(entry, ".RequiredLib") {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(entry))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			fatal(err)
+		}
+		if err := ioutil.WriteFile(target, extractEntry(f), 0644); err != nil {
+			fatal(err)
+		}
+		packageEntries[dir] = append(packageEntries[dir], f.Name)
+		if isArchive(entry) {
+			count++
+		}
+	}
+	if count == 0 {
+		fatalf("%v contains no JARs in a %v folder", filePath, strings.Join(packageLibDirs, ", "))
+	}
+	log.Infof("Analyzing %d JAR(s) in %v", count, filePath)
+	extractedPackages[dir] = filePath
+	return dir
+}
+
+// inLibDir tells whether an entry of an archive is in one of libDirs, at any
+// depth.
+func inLibDir(entry string, libDirs []string) bool {
+	parts := strings.Split(entry, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if contains(libDirs, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExtractedPackage tells whether a target is the extraction of a package,
+// which is read like a userlib with subdirectories.
+func isExtractedPackage(targetDir string) bool {
+	_, ok := extractedPackages[targetDir]
+	return ok
+}
+
+// reportPath shows a file of an extracted package as a path in the
+// package, like app.mda!/model/lib/userlib/foo.jar, and other paths without
+// the extended-length prefix of Windows. Files of a remote target are shown
+// as their URL.
+func reportPath(filePath string) string {
+	if url, ok := remotePath(filePath); ok {
+		return url
+	}
+	for dir, pkg := range extractedPackages {
+		if filePath == dir {
+			return pkg
+		}
+		if strings.HasPrefix(filePath, dir+string(filepath.Separator)) {
+			return pkg + nestedSeparator + filepath.ToSlash(filePath[len(dir)+1:])
+		}
+	}
+	return displayPath(filePath)
+}
+
+// reportFindings returns the findings of the run with the paths shown by
+// reportPath.
+func reportFindings() []Finding {
+	findings := allFindings()
+	if len(extractedPackages) == 0 && len(remoteTargets) == 0 {
+		return findings
+	}
+	for i := range findings {
+		files := []string{}
+		for _, filePath := range findings[i].Files {
+			files = append(files, reportPath(filePath))
+		}
+		findings[i].Files = files
+		if fix := findings[i].SuggestedFix; fix != nil {
+			findings[i].SuggestedFix = &SuggestedFix{Action: fix.Action, Target: reportPath(fix.Target), Detail: fix.Detail}
+		}
+	}
+	return findings
+}
+
+// checkRepackage fails before anything is cleaned if the copy of a package
+// would overwrite it.
+func checkRepackage(dir string, outputPath string) {
+	pkg := extractedPackages[dir]
+	abs, err := filepath.Abs(outputPath)
+	if err != nil {
+		fatal(err)
+	}
+	if absPkg, err := filepath.Abs(pkg); err == nil && abs == absPkg {
+		fatalf("--repackage must not overwrite %v", pkg)
+	}
+}
+
+// repackage writes a copy of the package extracted to dir to outputPath, without
+// the entries removed from dir while cleaning. All other entries are copied
+// as they are, compressed data, order and comments included.
+func repackage(dir string, outputPath string) {
+	pkg := extractedPackages[dir]
+	removed := make(map[string]bool)
+	for _, name := range packageEntries[dir] {
+		if !fileExists(filepath.Join(dir, filepath.FromSlash(path.Clean(name)))) {
+			removed[name] = true
+		}
+	}
+
+	archive, err := zip.OpenReader(pkg)
+	if err != nil {
+		fatalf("Unable to open %v: %v", pkg, err)
+	}
+	defer archive.Close()
+	var b bytes.Buffer
+	writer := zip.NewWriter(&b)
+	if err := writer.SetComment(archive.Comment); err != nil {
+		fatal(err)
+	}
+	for _, f := range archive.File {
+		if removed[f.Name] {
+			continue
+		}
+		if err := writer.Copy(f); err != nil {
+			fatalf("Unable to copy %v from %v: %v", f.Name, pkg, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(outputPath, b.Bytes(), 0644); err != nil {
+		fatalf("Unable to write %v: %v", outputPath, err)
+	}
+	var before int64
+	if info, err := os.Stat(pkg); err == nil {
+		before = info.Size()
+	}
+	log.Infof("Wrote %v without %d entries, %v instead of %v", outputPath, len(removed), formatBytes(int64(b.Len())), formatBytes(before))
+}
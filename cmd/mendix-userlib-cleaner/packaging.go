@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// packagingVariants are classifiers of JARs that repackage the plain JAR of
+// the same version, usually together with its dependencies.
+var packagingVariants = []string{"bundle", "all", "shaded", "jar-with-dependencies", "uber", "standalone"}
+
+// packagingPlain stands for the JAR without a classifier in --packaging-preference.
+const packagingPlain = "plain"
+
+// packaging returns the packaging variant of a JAR, plain for a JAR without
+// classifier, or an empty string for other classifiers like sources.
+func packaging(jar JarProperties) string {
+	if contains(packagingVariants, jar.classifier) {
+		return jar.classifier
+	}
+	base := strings.TrimSuffix(jar.fileName, filepath.Ext(jar.fileName))
+	for _, variant := range packagingVariants {
+		if strings.HasSuffix(base, "-"+variant) {
+			return variant
+		}
+	}
+	if jar.classifier == "" {
+		return packagingPlain
+	}
+	return ""
+}
+
+func packagingRank(variant string) int {
+	for i, preferred := range viper.GetStringSlice("packaging-preference") {
+		if preferred == variant {
+			return i
+		}
+	}
+	return len(packagingVariants) + 1
+}
+
+// applyPackagingPreference picks between packaging variants of the kept
+// version, like velocity-1.7.jar and velocity-1.7-shaded.jar, which compare as
+// equal versions, according to --packaging-preference.
+func applyPackagingPreference(jars []JarProperties, keepJars map[string]JarProperties) {
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		kept := keepJars[packageName]
+		variants := []JarProperties{}
+		for _, jar := range jars {
+			if jar.key() == packageName && compareVersions(jar.version, kept.version) == 0 && packaging(jar) != "" {
+				variants = append(variants, jar)
+			}
+		}
+		if len(variants) < 2 {
+			continue
+		}
+		sort.SliceStable(variants, func(i, j int) bool {
+			return packagingRank(packaging(variants[i])) < packagingRank(packaging(variants[j]))
+		})
+		best := variants[0]
+		if best.filePath != kept.filePath {
+			log.Infof("Preferring %v packaging %v over %v", packaging(best), best.fileName, kept.fileName)
+			keepJars[packageName] = best
+		}
+		for _, jar := range variants[1:] {
+			log.Warningf("%v is a %v packaging variant of kept %v", jar.fileName, packaging(jar), best.fileName)
+			addFinding(Finding{Type: "packaging-variant", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("%v and %v are %v and %v packaging of %v %v, %v is preferred", jar.fileName, best.fileName, packaging(jar), packaging(best), packageName, best.version, packaging(best)),
+				Files:        []string{jar.filePath},
+				SuggestedFix: removeFileFix(jar.filePath)})
+		}
+	}
+}
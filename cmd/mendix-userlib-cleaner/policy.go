@@ -0,0 +1,53 @@
+package main
+
+import "github.com/spf13/viper"
+
+// Policy collects the rules given by the user that change which JARs are kept
+// or what is required of them.
+type Policy struct {
+	keepOverrides   map[string]string
+	allowList       map[string]versionRange
+	constraints     map[string]versionRange
+	vulnerabilities map[string][]vulnerability
+	owners          map[string]Owner
+	requireOwners   bool
+	contentHash     bool
+	minConfidence   string
+}
+
+// rangesByIdentity keys the ranges of the configuration, which names packages
+// by group and artifact, by the key of the JARs they apply to with the
+// --identity in use.
+func rangesByIdentity(jars []JarProperties, ranges map[string]versionRange) map[string]versionRange {
+	if identity == identityGroupArtifact {
+		return ranges
+	}
+	keyed := make(map[string]versionRange)
+	for _, jar := range jars {
+		if vr, ok := ranges[jar.fullKey()]; ok {
+			keyed[jar.key()] = vr
+		}
+	}
+	return keyed
+}
+
+func loadPolicy() Policy {
+	policy := Policy{keepOverrides: parseKeepOverrides(viper.GetStringSlice("keep")), requireOwners: viper.GetBool("require-owners"), contentHash: viper.GetBool("content-hash"), minConfidence: viper.GetString("min-confidence")}
+	checkMinConfidence(policy.minConfidence)
+	checkRuntimeJavaVersion(viper.GetInt("java-version"))
+	// holds are applied while cleaning, invalid ones fail before the scan
+	parseHolds(viper.GetStringSlice("hold"))
+	if path := viper.GetString("allow-list"); path != "" {
+		policy.allowList = loadAllowList(path)
+	}
+	if path := viper.GetString("constraints"); path != "" {
+		policy.constraints = loadConstraints(path)
+	}
+	if path := viper.GetString("vulnerabilities"); path != "" {
+		policy.vulnerabilities = loadVulnerabilities(path)
+	}
+	if path := ownersFile(); path != "" {
+		policy.owners = loadOwners(path)
+	}
+	return policy
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// placeholder matches Maven property references like ${project.version}.
+var placeholder = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// maxPlaceholderDepth limits the resolution of properties referring to other
+// properties, which may refer to each other.
+const maxPlaceholderDepth = 10
+
+// pomProject holds the parts of a pom.xml used for identification.
+type pomProject struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Name       string `xml:"name"`
+	Parent     struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Organization struct {
+		Name string `xml:"name"`
+	} `xml:"organization"`
+	Licenses []struct {
+		Name string `xml:"name"`
+	} `xml:"licenses>license"`
+}
+
+// isPOMXML tells whether an entry is the pom.xml Maven embeds in a JAR,
+// META-INF/maven/<group>/<artifact>/pom.xml.
+func isPOMXML(name string) bool {
+	return strings.HasPrefix(name, "META-INF/maven/") && strings.HasSuffix(name, "/pom.xml") && strings.Count(name, "/") == 4
+}
+
+func hasPlaceholder(value string) bool {
+	return placeholder.MatchString(value)
+}
+
+// resolvePlaceholders replaces ${name} by the property name, also in the
+// values of other properties. Unknown properties are left as they are.
+func resolvePlaceholders(value string, properties map[string]string) string {
+	for depth := 0; depth < maxPlaceholderDepth && hasPlaceholder(value); depth++ {
+		resolved := placeholder.ReplaceAllStringFunc(value, func(match string) string {
+			if property, ok := properties[match[2:len(match)-1]]; ok {
+				return property
+			}
+			return match
+		})
+		if resolved == value {
+			break
+		}
+		value = resolved
+	}
+	return value
+}
+
+// properties returns the properties a pom.xml defines and those Maven
+// derives from the project and its parent, like project.version and
+// project.parent.version. A missing groupId or version is inherited from the
+// parent.
+func (project pomProject) properties() map[string]string {
+	properties := make(map[string]string)
+	for _, entry := range project.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	version := strings.TrimSpace(project.Version)
+	if version == "" {
+		version = strings.TrimSpace(project.Parent.Version)
+	}
+	groupID := strings.TrimSpace(project.GroupID)
+	if groupID == "" {
+		groupID = strings.TrimSpace(project.Parent.GroupID)
+	}
+	// pom. and unprefixed names are deprecated, but still found
+	for _, prefix := range []string{"project.", "pom.", ""} {
+		properties[prefix+"groupId"] = groupID
+		properties[prefix+"artifactId"] = strings.TrimSpace(project.ArtifactID)
+		properties[prefix+"version"] = version
+		properties[prefix+"parent.groupId"] = strings.TrimSpace(project.Parent.GroupID)
+		properties[prefix+"parent.artifactId"] = strings.TrimSpace(project.Parent.ArtifactID)
+		properties[prefix+"parent.version"] = strings.TrimSpace(project.Parent.Version)
+	}
+	return properties
+}
+
+// parsePOMXML identifies a JAR by its embedded pom.xml. A missing groupId or
+// version is inherited from the parent, as Maven does, and placeholders like
+// ${revision} are resolved from the properties of the pom.xml. A version that
+// cannot be resolved is left empty.
+func parsePOMXML(filePath string, content []byte) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	project := pomProject{}
+	if err := xml.Unmarshal(content, &project); err != nil {
+		log.Debugf("Unable to parse pom.xml of %v: %v", filePath, err)
+		return jarProp
+	}
+	properties := project.properties()
+	groupID := resolvePlaceholders(properties["project.groupId"], properties)
+	artifactID := resolvePlaceholders(properties["project.artifactId"], properties)
+	version := resolvePlaceholders(properties["project.version"], properties)
+	if hasPlaceholder(version) {
+		log.Debugf("Unable to resolve version %v in pom.xml of %v", version, filePath)
+		version = ""
+	}
+	if groupID == "" || artifactID == "" || hasPlaceholder(groupID) || hasPlaceholder(artifactID) {
+		return jarProp
+	}
+	jarProp.group = groupID
+	jarProp.artifact = artifactID
+	jarProp.version = version
+	jarProp.name = strings.TrimSpace(project.Name)
+	jarProp.vendor = strings.TrimSpace(project.Organization.Name)
+	if len(project.Licenses) > 0 {
+		jarProp.license = strings.TrimSpace(project.Licenses[0].Name)
+	}
+	return jarProp
+}
+
+// resolvePOMVersion resolves a placeholder left in the version of
+// pom.properties, like ${revision}, from the pom.xml next to it, or else
+// takes the version from the file name.
+func resolvePOMVersion(archive *zip.Reader, pomProperties string, jarProp JarProperties, logger jarLog) string {
+	for _, f := range archive.File {
+		if f.Name != path.Dir(pomProperties)+"/pom.xml" {
+			continue
+		}
+		if version := parsePOMXML(jarProp.filePath, extractEntry(f)).version; version != "" {
+			logger.Debugf("Resolved version %v of %v from pom.xml: %v", jarProp.version, jarProp.fileName, version)
+			return version
+		}
+	}
+	version := versionFromFileName(jarProp.filePath)
+	logger.Debugf("Unable to resolve version %v of %v, using %q from the file name", jarProp.version, jarProp.fileName, version)
+	return version
+}
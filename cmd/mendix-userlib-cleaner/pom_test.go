@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestParsePOMXML(t *testing.T) {
+	tests := []struct {
+		name                     string
+		content                  string
+		group, artifact, version string
+	}{
+		{"project", `<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>1.0</version></project>`,
+			"org.example", "lib", "1.0"},
+		{"inherited group and version", `<project>
+			<parent><groupId>org.example</groupId><artifactId>parent</artifactId><version>2.1</version></parent>
+			<artifactId>lib</artifactId>
+		</project>`,
+			"org.example", "lib", "2.1"},
+		{"own version over the parent", `<project>
+			<parent><groupId>org.example</groupId><artifactId>parent</artifactId><version>2.1</version></parent>
+			<groupId>org.other</groupId><artifactId>lib</artifactId><version>3.0</version>
+		</project>`,
+			"org.other", "lib", "3.0"},
+		{"parent properties", `<project>
+			<parent><groupId>org.example</groupId><artifactId>parent</artifactId><version>2.1</version></parent>
+			<artifactId>lib</artifactId><version>${project.parent.version}</version>
+		</project>`,
+			"org.example", "lib", "2.1"},
+		{"revision", `<project>
+			<groupId>org.example</groupId><artifactId>lib</artifactId><version>${revision}${changelist}</version>
+			<properties><revision>1.4</revision><changelist>-SNAPSHOT</changelist></properties>
+		</project>`,
+			"org.example", "lib", "1.4-SNAPSHOT"},
+		{"nested properties", `<project>
+			<groupId>${base.group}.sub</groupId><artifactId>lib</artifactId><version>${lib.version}</version>
+			<properties><base.group>org.example</base.group><major>5</major><lib.version>${major}.2</lib.version></properties>
+		</project>`,
+			"org.example.sub", "lib", "5.2"},
+		{"unresolved version", `<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>${revision}</version></project>`,
+			"org.example", "lib", ""},
+		{"cyclic properties", `<project>
+			<groupId>org.example</groupId><artifactId>lib</artifactId><version>${a}</version>
+			<properties><a>${b}</a><b>${a}</b></properties>
+		</project>`,
+			"org.example", "lib", ""},
+		{"no group", `<project><artifactId>lib</artifactId><version>1.0</version></project>`,
+			"", "", ""},
+		{"invalid", `<project><groupId>org.example`,
+			"", "", ""},
+	}
+	for _, test := range tests {
+		jar := parsePOMXML("userlib/lib.jar", []byte(test.content))
+		if jar.group != test.group || jar.artifact != test.artifact || jar.version != test.version {
+			t.Errorf("%v: parsePOMXML() = %v:%v:%v, want %v:%v:%v", test.name, jar.group, jar.artifact, jar.version, test.group, test.artifact, test.version)
+		}
+	}
+}
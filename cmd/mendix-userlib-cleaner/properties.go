@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseProperties reads a .properties file like pom.properties the way
+// java.util.Properties.load does: ISO-8859-1 text with \uXXXX escapes, # and
+// ! comments, key=value, key:value and key value pairs, and lines continued
+// by a trailing backslash.
+func parseProperties(b []byte) map[string]string {
+	// ISO-8859-1 maps every byte to the code point of the same value
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	text := strings.Replace(strings.Replace(string(runes), "\r\n", "\n", -1), "\r", "\n", -1)
+
+	properties := make(map[string]string)
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+		key, value := splitProperty(line)
+		properties[unescapeProperty(key)] = unescapeProperty(value)
+	}
+	return properties
+}
+
+// continues tells whether a line ends with an odd number of backslashes, so
+// the last one is not escaped and continues the line.
+func continues(line string) bool {
+	backslashes := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+// splitProperty splits a logical line at the first unescaped =, : or
+// whitespace, which may be surrounded by whitespace.
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	key, rest := line[:end], strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty resolves \t, \n, \r, \f and \uXXXX; a backslash before
+// any other character stands for that character.
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i+1 == len(runes) {
+			b.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 't':
+			b.WriteRune('\t')
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 'f':
+			b.WriteRune('\f')
+		case 'u':
+			if i+4 < len(runes) {
+				if code, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 16); err == nil {
+					b.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			b.WriteRune('u')
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{"pom.properties", "#Generated by Maven\n#Tue Jan 10 12:00:00 UTC 2024\ngroupId=org.example\nartifactId=lib\nversion=1.0\n",
+			map[string]string{"groupId": "org.example", "artifactId": "lib", "version": "1.0"}},
+		{"separators", "a=1\nb:2\nc 3\n  d =  4\ne\t:\t5\nf\n",
+			map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5", "f": ""}},
+		{"comments", "# a=1\n! b=2\n   # c=3\nd=4",
+			map[string]string{"d": "4"}},
+		{"line endings", "a=1\r\nb=2\rc=3",
+			map[string]string{"a": "1", "b": "2", "c": "3"}},
+		{"continuation", "version=1.\\\n    2.\\\n\t3\nname=x\\\n",
+			map[string]string{"version": "1.2.3", "name": "x"}},
+		{"escaped backslash", "path=C:\\\\lib\\\\\nnext=1",
+			map[string]string{"path": "C:\\lib\\", "next": "1"}},
+		{"escaped separators", "a\\=b=c\nd\\:e:f\ng\\ h i",
+			map[string]string{"a=b": "c", "d:e": "f", "g h": "i"}},
+		{"unicode escapes", "name=Caf\\u00e9\nsign=\\u20AC\nbad=\\u12",
+			map[string]string{"name": "Café", "sign": "€", "bad": "u12"}},
+		{"character escapes", "a=x\\ty\\nz\nb=\\q",
+			map[string]string{"a": "x\ty\nz", "b": "q"}},
+		{"ISO-8859-1", "name=Caf\xe9",
+			map[string]string{"name": "Café"}},
+	}
+	for _, test := range tests {
+		if got := parseProperties([]byte(test.content)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: parseProperties() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// quarantineLayout names the folder each cleaning run moves its files to.
+const quarantineLayout = "20060102-150405"
+
+var quarantineRun string
+
+// targetLabel names the target a run cleans when there are several, their
+// files are quarantined in a folder of that name.
+var targetLabel string
+
+// disposedBytes is the size of the files disposed of for the target.
+var disposedBytes int64
+
+// disposeFile removes a file, or moves it to the folder of this run in the
+// --quarantine directory so it can be recovered.
+func disposeFile(filePath string) error {
+	var size int64
+	if info, err := os.Stat(filePath); err == nil {
+		size = info.Size()
+	}
+	dir := viper.GetString("quarantine")
+	if dir == "" {
+		if err := removeFile(filePath); err != nil {
+			return err
+		}
+		disposedBytes += size
+		return nil
+	}
+	if quarantineRun == "" {
+		quarantineRun = filepath.Join(dir, time.Now().Format(quarantineLayout))
+		if err := os.MkdirAll(quarantineRun, 0755); err != nil {
+			return err
+		}
+	}
+	// files from subdirectories of the target keep their relative path
+	newPath := filepath.Join(quarantineRun, targetLabel, filepath.FromSlash(relativePath(guardedTarget, filePath)))
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := renameFile(filePath, newPath); err != nil {
+		return err
+	}
+	disposedBytes += size
+	return nil
+}
+
+// parseRetention parses durations like 30d, 2w or 12h.
+func parseRetention(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid retention %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
+
+type quarantineFolder struct {
+	path    string
+	created time.Time
+	files   []quarantinedFile
+	// subdirectories of files from subdirectories of the target, deepest
+	// first
+	dirs []string
+}
+
+// quarantinedFile is a file by its path relative to its quarantine folder.
+type quarantinedFile struct {
+	name string
+	size int64
+}
+
+// listQuarantine returns the run folders in the quarantine, named by
+// quarantineLayout, oldest first.
+func listQuarantine(dir string) []quarantineFolder {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warningf("Unable to read quarantine: %v", err)
+		}
+		return nil
+	}
+	folders := []quarantineFolder{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		// only run folders are listed, other folders are not the tool's
+		created, err := time.ParseInLocation(quarantineLayout, entry.Name(), time.Local)
+		if err != nil {
+			log.Debugf("Skipping %v in the quarantine, it is no run folder", entry.Name())
+			continue
+		}
+		folder := quarantineFolder{path: filepath.Join(dir, entry.Name()), created: created}
+		filepath.Walk(folder.path, func(path string, info os.FileInfo, err error) error {
+			if err != nil || path == folder.path {
+				return nil
+			}
+			rel, _ := filepath.Rel(folder.path, path)
+			if info.IsDir() {
+				folder.dirs = append([]string{path}, folder.dirs...)
+			} else {
+				folder.files = append(folder.files, quarantinedFile{filepath.ToSlash(rel), info.Size()})
+			}
+			return nil
+		})
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].created.Before(folders[j].created) })
+	return folders
+}
+
+// pruneQuarantine removes quarantined files older than the retention and
+// the folders left empty.
+func pruneQuarantine(dir string, retention time.Duration) {
+	pruned := 0
+	for _, folder := range listQuarantine(dir) {
+		if time.Since(folder.created) >= retention {
+			for _, file := range folder.files {
+				if err := removeFile(filepath.Join(folder.path, filepath.FromSlash(file.name))); err != nil {
+					log.Warningf("Unable to prune %v: %v", file.name, err)
+					continue
+				}
+				pruned++
+			}
+		}
+		// fails unless the folder is empty
+		for _, subdir := range folder.dirs {
+			removeEmptyDir(subdir)
+		}
+		if err := removeEmptyDir(folder.path); err == nil {
+			log.Debugf("Removed quarantine folder %v", folder.path)
+		}
+	}
+	if pruned > 0 {
+		log.Infof("Pruned %d quarantined file(s) older than %v", pruned, retention)
+	}
+}
+
+// backups implements the backups command.
+func backups(args []string, dir string) {
+	if len(args) == 0 || args[0] != "list" {
+		fatal("Usage: backups list")
+	}
+	if dir == "" {
+		fatal("Use --quarantine to set the quarantine directory")
+	}
+	folders := listQuarantine(dir)
+	total := 0
+	for _, folder := range folders {
+		if len(folder.files) == 0 {
+			continue
+		}
+		var size int64
+		for _, file := range folder.files {
+			size += file.size
+		}
+		log.Infof("%v (%v): %d file(s), %v", folder.path, folder.created.Format(time.RFC3339), len(folder.files), formatBytes(size))
+		for _, file := range folder.files {
+			log.Infof("  %v", file.name)
+		}
+		total += len(folder.files)
+	}
+	log.Infof("%d file(s) can be recovered by copying them back into userlib", total)
+}
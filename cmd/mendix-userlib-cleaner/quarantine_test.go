@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneQuarantineOnlyRunFolders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20200101-000000/old.jar", "my-notes/keepme.jar"} {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filePath, []byte("jar"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "my-notes"), old, old)
+
+	folders := listQuarantine(dir)
+	if len(folders) != 1 || filepath.Base(folders[0].path) != "20200101-000000" {
+		t.Fatalf("got folders %v, want only the run folder", folders)
+	}
+	allowMutationsIn(dir)
+	pruneQuarantine(dir, time.Hour)
+	if fileExists(filepath.Join(dir, "20200101-000000", "old.jar")) {
+		t.Error("old.jar was not pruned")
+	}
+	if !fileExists(filepath.Join(dir, "my-notes", "keepme.jar")) {
+		t.Error("keepme.jar outside the run folders was pruned")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed relocations.yaml
+var embeddedRelocations []byte
+
+type relocationEntry struct {
+	Library   string   `yaml:"library"`
+	Relocated []string `yaml:"relocated"`
+	Note      string   `yaml:"note"`
+}
+
+// equivalence names the library a JAR belongs to under other coordinates.
+type equivalence struct {
+	library string
+	note    string
+}
+
+var (
+	equivalencesOnce sync.Once
+	equivalences     map[string]equivalence
+	// aliases map user-defined package names or group:artifact to the
+	// library they are treated as, see setAliases
+	aliases = make(map[string]string)
+)
+
+// loadEquivalences returns the known relocations by the normalized
+// group:artifact of both the relocated and the target artifact, loaded once.
+func loadEquivalences() map[string]equivalence {
+	equivalencesOnce.Do(func() {
+		equivalences = make(map[string]equivalence)
+		entries := []relocationEntry{}
+		if err := yaml.Unmarshal(embeddedRelocations, &entries); err != nil {
+			fatalf("Invalid relocation database: %v", err)
+		}
+		for _, entry := range entries {
+			equivalences[normalizeKey(entry.Library)] = equivalence{library: entry.Library}
+			for _, relocated := range entry.Relocated {
+				equivalences[normalizeKey(relocated)] = equivalence{library: entry.Library, note: entry.Note}
+			}
+		}
+	})
+	return equivalences
+}
+
+// libraryKey is the key of a library given as group:artifact or as a
+// package name.
+func libraryKey(library string) string {
+	if i := strings.LastIndex(library, ":"); i >= 0 {
+		return Coordinates{group: library[:i], artifact: library[i+1:]}.key()
+	}
+	return normalizeKey(library)
+}
+
+// applyEquivalences groups a JAR of a relocated artifact, like log4j:log4j,
+// with the library it is known as today, like ch.qos.reload4j:reload4j, and
+// notes the relocation for the report.
+func applyEquivalences(jarProp *JarProperties, logger jarLog) {
+	if jarProp.group == "" || isUnidentified(*jarProp) {
+		return
+	}
+	coordinates := jarProp.group + ":" + jarProp.artifact
+	e, ok := loadEquivalences()[normalizeKey(coordinates)]
+	if !ok {
+		return
+	}
+	jarProp.alias = libraryKey(e.library)
+	if normalizeKey(coordinates) != normalizeKey(e.library) {
+		jarProp.relocation = fmt.Sprintf("%v is known as %v: %v", coordinates, e.library, e.note)
+		logger.Infof("Grouping %v with %v, %v", jarProp.fileName, e.library, e.note)
+	}
+}
+
+// setAliases reads a YAML file mapping package names or group:artifact of
+// e.g. internal forks and renamed artifacts to the library they are the same
+// as, e.g.
+//
+//	com.acme:commons-lang3-patched: org.apache.commons:commons-lang3
+//	com.acme.legacy-pdf: org.apache.pdfbox.pdfbox
+func setAliases(path string) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read aliases: %v", err)
+	}
+	entries := make(map[string]string)
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		fatalf("Unable to parse aliases %v: %v", path, err)
+	}
+	aliases = make(map[string]string)
+	for alias, library := range entries {
+		if strings.TrimSpace(library) == "" {
+			fatalf("Alias %v in %v has no library", alias, path)
+		}
+		aliases[normalizeKey(alias)] = library
+	}
+	log.Infof("Loaded %d alias(es) from %v", len(aliases), path)
+}
+
+// applyAliases groups a JAR matching a user-defined alias, by group:artifact
+// or by package name, with the library it is an alias of. Aliases take
+// precedence over the relocation database.
+func applyAliases(jarProp *JarProperties) {
+	unaliased := jarProp.Coordinates
+	unaliased.alias = ""
+	candidates := []string{unaliased.key(), jarProp.key()}
+	if jarProp.group != "" {
+		candidates = append([]string{normalizeKey(jarProp.group + ":" + jarProp.artifact)}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if library, ok := aliases[candidate]; ok {
+			jarProp.alias = libraryKey(library)
+			jarProp.aliasOf = library
+			return
+		}
+	}
+}
+
+// logAliases tells which JARs are grouped by a user-defined alias.
+func logAliases(jars []JarProperties) {
+	for _, jar := range jars {
+		if jar.aliasOf != "" {
+			log.Infof("Grouping %v with %v by alias", jar.fileName, jar.aliasOf)
+		}
+	}
+}
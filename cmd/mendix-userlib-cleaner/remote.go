@@ -0,0 +1,321 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// remoteBlockSize is the size of the ranges read from objects. The central
+// directory and the metadata of most JARs fit in a few blocks.
+const remoteBlockSize = 256 << 10
+
+// objectStore is a bucket or container holding a userlib, like an artifact
+// archive. Keys are relative to the bucket.
+type objectStore interface {
+	list(prefix string, recursive bool) ([]remoteObject, error)
+	read(key string, offset int64, length int64) ([]byte, error)
+	open(key string) (io.ReadCloser, error)
+	remove(key string) error
+}
+
+type remoteObject struct {
+	key  string
+	size int64
+}
+
+// remoteTarget is a userlib in an object store, mirrored to a temporary
+// directory for the analysis.
+type remoteTarget struct {
+	url    string
+	store  objectStore
+	prefix string
+	// keys of the mirrored files by their path in the mirror
+	keys map[string]string
+	// sizes of the objects by their path in the mirror
+	sizes map[string]int64
+}
+
+// remoteTargets maps the temporary directory a remote userlib is mirrored
+// to, to the remote userlib.
+var remoteTargets = make(map[string]*remoteTarget)
+
+func isRemote(target string) bool {
+	return strings.HasPrefix(target, "s3://") || strings.HasPrefix(target, "az://")
+}
+
+// openObjectStore returns the store and the prefix of the keys of a URL like
+// s3://bucket/path/userlib or az://account/container/path/userlib.
+func openObjectStore(url string) (objectStore, string) {
+	scheme := url[:strings.Index(url, "://")]
+	parts := strings.SplitN(strings.TrimPrefix(url, scheme+"://"), "/", 3)
+	switch {
+	case scheme == "s3" && parts[0] != "":
+		return newS3Store(parts[0]), strings.Trim(strings.Join(parts[1:], "/"), "/")
+	case scheme == "az" && len(parts) >= 2 && parts[0] != "" && parts[1] != "":
+		prefix := ""
+		if len(parts) == 3 {
+			prefix = strings.Trim(parts[2], "/")
+		}
+		return newAzureStore(parts[0], parts[1]), prefix
+	}
+	fatalf("Invalid remote target %v, use s3://bucket/path or az://account/container/path", url)
+	return nil, ""
+}
+
+// mirrorRemote lists a remote userlib and writes a stand-in for each JAR to
+// a temporary directory, without downloading the JARs: the central
+// directory is read with range requests and every entry but the classes is
+// copied. Classes keep their header, with the CRC-32 and size of their
+// content, so JARs differing in any class still differ, and a sample of them
+// is copied for the bytecode checks. .meta and .RequiredLib files are copied
+// as they are.
+func mirrorRemote(url string) string {
+	store, prefix := openObjectStore(url)
+	listPrefix := prefix
+	if listPrefix != "" {
+		listPrefix += "/"
+	}
+	objects, err := store.list(listPrefix, viper.GetBool("recursive"))
+	if err != nil {
+		fatalf("Unable to list %v: %v", url, err)
+	}
+	root, err := ioutil.TempDir(tempDir(), "remote")
+	if err != nil {
+		fatal(err)
+	}
+	dir := filepath.Join(root, path.Base("/"+prefix))
+	if prefix == "" {
+		dir = filepath.Join(root, "root")
+	}
+	remote := &remoteTarget{url: strings.TrimSuffix(url, "/"), store: store, prefix: listPrefix,
+		keys: make(map[string]string), sizes: make(map[string]int64)}
+
+	count := 0
+	var transferred int64
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.key, listPrefix)
+		entry := path.Clean(name)
+		if name == "" || strings.HasSuffix(object.key, "/") || strings.HasPrefix(entry, "../") {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(entry))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			fatal(err)
+		}
+		reader := &remoteReader{store: store, key: object.key, size: object.size, blocks: make(map[int64][]byte)}
+		switch {
+		case isArchive(entry):
+			if err := writeStandIn(reader, target); err != nil {
+				log.Warningf("Unable to read %v/%v: %v", remote.url, name, err)
+				skipFile(remote.url+"/"+name, err.Error())
+				continue
+			}
+			count++
+		case strings.HasSuffix(entry, ".meta") || strings.HasSuffix(entry, ".RequiredLib"):
+			b, err := reader.readAll()
+			if err != nil {
+				fatalf("Unable to read %v/%v: %v", remote.url, name, err)
+			}
+			if err := ioutil.WriteFile(target, b, 0644); err != nil {
+				fatal(err)
+			}
+		default:
+			continue
+		}
+		transferred += reader.transferred
+		remote.keys[target] = object.key
+		remote.sizes[target] = object.size
+	}
+	log.Infof("Analyzing %d JAR(s) in %v, read %v of them", count, url, formatBytes(transferred))
+	remoteTargets[dir] = remote
+	return dir
+}
+
+// writeStandIn writes a JAR with the entries of a remote JAR to target.
+func writeStandIn(reader *remoteReader, target string) error {
+	archive, err := zip.NewReader(reader, reader.size)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	sampled := 0
+	for _, f := range archive.File {
+		isClass := strings.HasSuffix(f.Name, ".class") && !strings.HasSuffix(f.Name, "module-info.class")
+		if isClass && classNameFromEntry(f.Name) != "" && sampled < sampledClasses {
+			sampled++
+			isClass = false
+		}
+		if !isClass {
+			if err := writer.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+		header := f.FileHeader
+		header.CompressedSize64 = 0
+		if _, err := writer.CreateRaw(&header); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+var (
+	remoteChecksumsMutex sync.Mutex
+	remoteChecksums      = make(map[string]string)
+)
+
+// isRemoteStandIn tells whether filePath is the stand-in of a remote JAR,
+// whose classes are left out.
+func isRemoteStandIn(filePath string) bool {
+	_, ok := remoteKey(filePath)
+	return ok && isArchive(filePath)
+}
+
+func remoteKey(filePath string) (*remoteTarget, bool) {
+	for _, remote := range remoteTargets {
+		if _, ok := remote.keys[filePath]; ok {
+			return remote, true
+		}
+	}
+	return nil, false
+}
+
+// remoteChecksum hashes the object a stand-in was written for, so that
+// checksums of remote JARs match those of the published artifacts.
+func remoteChecksum(filePath string, algorithm string, newHash func() hash.Hash) (string, error) {
+	remoteChecksumsMutex.Lock()
+	checksum, ok := remoteChecksums[algorithm+":"+filePath]
+	remoteChecksumsMutex.Unlock()
+	if ok {
+		return checksum, nil
+	}
+	remote, _ := remoteKey(filePath)
+	body, err := remote.store.open(remote.keys[filePath])
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	checksum, err = readChecksum(body, newHash)
+	if err != nil {
+		return "", err
+	}
+	remoteChecksumsMutex.Lock()
+	remoteChecksums[algorithm+":"+filePath] = checksum
+	remoteChecksumsMutex.Unlock()
+	return checksum, nil
+}
+
+// remoteSize returns the size of a remote object mirrored to filePath.
+func remoteSize(filePath string) (int64, bool) {
+	for _, remote := range remoteTargets {
+		if size, ok := remote.sizes[filePath]; ok {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// remotePath shows a mirrored file as its URL.
+func remotePath(filePath string) (string, bool) {
+	for dir, remote := range remoteTargets {
+		if filePath == dir {
+			return remote.url, true
+		}
+		if strings.HasPrefix(filePath, dir+string(filepath.Separator)) {
+			return remote.url + "/" + filepath.ToSlash(filePath[len(dir)+1:]), true
+		}
+	}
+	return "", false
+}
+
+// removeRemoteObjects deletes the objects whose stand-ins were removed from
+// the mirror in dir while cleaning.
+func removeRemoteObjects(dir string) {
+	remote := remoteTargets[dir]
+	removed := 0
+	for filePath, key := range remote.keys {
+		if fileExists(filePath) {
+			continue
+		}
+		if err := remote.store.remove(key); err != nil {
+			log.Errorf("Unable to delete %v/%v: %v", remote.url, strings.TrimPrefix(key, remote.prefix), err)
+			continue
+		}
+		log.Debugf("Deleted %v from the store", key)
+		removed++
+	}
+	log.Infof("Deleted %d object(s) from %v", removed, remote.url)
+}
+
+// remoteReader reads an object in blocks with range requests.
+type remoteReader struct {
+	store       objectStore
+	key         string
+	size        int64
+	blocks      map[int64][]byte
+	transferred int64
+}
+
+func (r *remoteReader) ReadAt(p []byte, offset int64) (int, error) {
+	if offset >= r.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && offset+int64(n) < r.size {
+		position := offset + int64(n)
+		block, err := r.block(position / remoteBlockSize)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[position%remoteBlockSize:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *remoteReader) block(index int64) ([]byte, error) {
+	if block, ok := r.blocks[index]; ok {
+		return block, nil
+	}
+	offset := index * remoteBlockSize
+	length := int64(remoteBlockSize)
+	if offset+length > r.size {
+		length = r.size - offset
+	}
+	block, err := r.store.read(r.key, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(block)) != length {
+		return nil, fmt.Errorf("read %d of %d bytes at %d", len(block), length, offset)
+	}
+	r.blocks[index] = block
+	r.transferred += length
+	return block, nil
+}
+
+func (r *remoteReader) readAll() ([]byte, error) {
+	b := make([]byte, r.size)
+	if _, err := r.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b, nil
+}
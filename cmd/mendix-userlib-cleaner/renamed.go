@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// renamedCopies finds files that are not named like JARs but have the same
+// content as one, like foo.jar.bak, foo.jar.old or foo.jar (1) left behind by
+// manual backups and Windows copies. Each is returned as a copy of that JAR,
+// with the same coordinates, so it is removed as its duplicate. Only files of
+// the size of a JAR are hashed.
+func renamedCopies(filePaths []string, jars []JarProperties) []JarProperties {
+	bySize := make(map[int64][]JarProperties)
+	for _, jar := range jars {
+		bySize[jar.size] = append(bySize[jar.size], jar)
+	}
+	hashes := make(map[string]string)
+	hash := func(filePath string) string {
+		if _, ok := hashes[filePath]; !ok {
+			value, err := fileSHA256(filePath)
+			if err != nil {
+				log.Warningf("Unable to hash %v: %v", filePath, err)
+			}
+			hashes[filePath] = value
+		}
+		return hashes[filePath]
+	}
+
+	copies := []JarProperties{}
+	for _, filePath := range filePaths {
+		if isArchive(filePath) {
+			continue
+		}
+		info, err := os.Stat(filePath)
+		if err != nil || len(bySize[info.Size()]) == 0 {
+			continue
+		}
+		for _, jar := range bySize[info.Size()] {
+			if value := hash(filePath); value == "" || value != hash(jar.filePath) {
+				continue
+			}
+			log.Infof("%v is a renamed copy of %v", filepath.Base(filePath), jar.fileName)
+			unskipFile(filePath)
+			allowVerifiedCopy(filePath, hash(filePath))
+			duplicate := jar
+			duplicate.filePath, duplicate.fileName, duplicate.copyOf = filePath, filepath.Base(filePath), jar.fileName
+			copies = append(copies, duplicate)
+			break
+		}
+	}
+	return copies
+}
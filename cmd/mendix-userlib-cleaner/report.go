@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+type Report struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Target        string          `json:"target"`
+	Mode          string          `json:"mode"`
+	Clean         bool            `json:"clean"`
+	GroupBy       string          `json:"groupBy"`
+	Groups        []ReportGroup   `json:"groups"`
+	Jars          []JarReport     `json:"jars"`
+	Findings      []Finding       `json:"findings"`
+	Degraded      []DegradedCheck `json:"degraded,omitempty"`
+}
+
+// ReportGroup lists the JARs sharing a module, vendor or package, split by
+// ownership since module-owned JARs are fixed by updating the module while
+// shared ones have to be consolidated manually.
+type ReportGroup struct {
+	Key         string   `json:"key"`
+	ModuleOwned []string `json:"moduleOwned"`
+	Shared      []string `json:"shared"`
+}
+
+type JarReport struct {
+	FileName      string            `json:"fileName"`
+	FilePath      string            `json:"filePath"`
+	RelativePath  string            `json:"relativePath"`
+	Package       string            `json:"package"`
+	Group         string            `json:"group,omitempty"`
+	Artifact      string            `json:"artifact"`
+	Version       string            `json:"version"`
+	Snapshot      bool              `json:"snapshot,omitempty"`
+	PreRelease    bool              `json:"preRelease,omitempty"`
+	Classifier    string            `json:"classifier,omitempty"`
+	Packaging     string            `json:"packaging"`
+	Variants      []string          `json:"variants,omitempty"`
+	Size          int64             `json:"size"`
+	Name          string            `json:"name,omitempty"`
+	Vendor        string            `json:"vendor,omitempty"`
+	License       string            `json:"license,omitempty"`
+	Owner         string            `json:"owner,omitempty"`
+	Justification string            `json:"justification,omitempty"`
+	ModuleKind    string            `json:"moduleKind"`
+	ModuleName    string            `json:"moduleName,omitempty"`
+	MultiRelease  bool              `json:"multiRelease,omitempty"`
+	Shaded        []string          `json:"shaded,omitempty"`
+	Relocation    string            `json:"relocation,omitempty"`
+	AliasOf       string            `json:"aliasOf,omitempty"`
+	Confidence    string            `json:"confidence"`
+	Alternatives  []string          `json:"alternatives,omitempty"`
+	CopyOf        string            `json:"copyOf,omitempty"`
+	JavaVersion   string            `json:"javaVersion,omitempty"`
+	ResourceOnly  bool              `json:"resourceOnly,omitempty"`
+	Nested        []NestedJarReport `json:"nested,omitempty"`
+	Natives       []string          `json:"natives,omitempty"`
+	FragmentHost  string            `json:"fragmentHost,omitempty"`
+	Modules       []string          `json:"modules,omitempty"`
+	Ownership     string            `json:"ownership"`
+	Classpath     int               `json:"classpathPosition"`
+	FirstSeen     string            `json:"firstSeen,omitempty"`
+	LastChanged   string            `json:"lastChanged,omitempty"`
+	Kept          bool              `json:"kept"`
+	VersionOrder  string            `json:"versionOrder,omitempty"`
+}
+
+// NestedJarReport identifies a JAR nested in another one.
+type NestedJarReport struct {
+	Path    string `json:"path"`
+	Package string `json:"package"`
+	Version string `json:"version"`
+}
+
+func nestedJarReports(nested []JarProperties) []NestedJarReport {
+	reports := []NestedJarReport{}
+	for _, jar := range nested {
+		reports = append(reports, NestedJarReport{Path: jar.filePath, Package: jar.key(), Version: jar.version})
+	}
+	if len(reports) == 0 {
+		return nil
+	}
+	return reports
+}
+
+func buildReport(targetDir string, mode string, clean bool, jars []JarProperties, keepJars map[string]JarProperties, groupBy string) Report {
+	if !contains([]string{"module", "vendor", "package"}, groupBy) {
+		fatalf("Unsupported --group-by: %v", groupBy)
+	}
+	report := Report{SchemaVersion: reportSchemaVersion, Target: reportPath(targetDir), Mode: mode, Clean: clean, GroupBy: groupBy, Groups: []ReportGroup{}, Jars: []JarReport{}, Findings: reportFindings(), Degraded: degradedChecks()}
+	positions := make(map[string]int)
+	for i, jar := range classpathOrder(jars) {
+		positions[jar.filePath] = i + 1
+	}
+	groups := make(map[string]*ReportGroup)
+	for _, jar := range jars {
+		for _, key := range groupKeys(jar, groupBy) {
+			group, ok := groups[key]
+			if !ok {
+				group = &ReportGroup{Key: key, ModuleOwned: []string{}, Shared: []string{}}
+				groups[key] = group
+			}
+			if ownership(jar) == ownershipModule {
+				group.ModuleOwned = append(group.ModuleOwned, jar.fileName)
+			} else {
+				group.Shared = append(group.Shared, jar.fileName)
+			}
+		}
+
+		kept, ok := keepJars[jar.key()]
+		order := ""
+		if ok && kept.filePath != jar.filePath {
+			order = "kept " + versionOrder(kept.version, jar.version)
+		}
+		report.Jars = append(report.Jars, JarReport{
+			FileName:      jar.fileName,
+			FilePath:      reportPath(jar.filePath),
+			RelativePath:  relativePath(targetDir, jar.filePath),
+			Package:       jar.key(),
+			Group:         jar.group,
+			Artifact:      jar.artifact,
+			Version:       jar.version,
+			Snapshot:      isSnapshot(jar.version),
+			PreRelease:    isPreRelease(jar.version),
+			Classifier:    jar.classifier,
+			Packaging:     jar.packaging,
+			Variants:      jar.variants,
+			Size:          jar.size,
+			Name:          jar.name,
+			Vendor:        jar.vendor,
+			License:       jar.license,
+			Owner:         jar.owner,
+			Justification: jar.justification,
+			ModuleKind:    jar.moduleKind,
+			ModuleName:    jar.moduleName,
+			MultiRelease:  jar.multiRelease,
+			Shaded:        jar.shaded,
+			Relocation:    jar.relocation,
+			AliasOf:       jar.aliasOf,
+			Confidence:    jar.confidence,
+			Alternatives:  jar.alternatives,
+			CopyOf:        jar.copyOf,
+			JavaVersion:   javaRelease(jar.classVersion),
+			ResourceOnly:  jar.resourceOnly,
+			Nested:        nestedJarReports(jar.nested),
+			Natives:       jar.natives,
+			FragmentHost:  jar.fragmentHost,
+			Modules:       jar.modules,
+			Ownership:     ownership(jar),
+			Classpath:     positions[jar.filePath],
+			FirstSeen:     formatTime(jar.firstSeen),
+			LastChanged:   formatTime(jar.lastChanged),
+			Kept:          kept.filePath == jar.filePath,
+			VersionOrder:  order,
+		})
+	}
+	for _, group := range groups {
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Key < report.Groups[j].Key })
+	return report
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeReport(path string, report Report) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, b, 0644); err != nil {
+		return err
+	}
+	log.Infof("Report written to %v", path)
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const mavenCentral = "https://repo1.maven.org/maven2"
+
+// Credentials of the repository are best passed in the environment, so they
+// do not show up in process listings or the project config.
+const (
+	repositoryTokenEnv    = "USERLIB_CLEANER_REPOSITORY_TOKEN"
+	repositoryPasswordEnv = "USERLIB_CLEANER_REPOSITORY_PASSWORD"
+)
+
+var errArtifactNotFound = errors.New("not found in the repository")
+
+// repository reads artifacts and metadata from a Maven repository, Maven
+// Central or an internal Nexus or Artifactory reached with a token or a user
+// and password.
+type repository struct {
+	baseURL  string
+	user     string
+	password string
+	token    string
+	client   http.Client
+}
+
+func newRepository() repository {
+	r := repository{
+		baseURL:  strings.TrimSuffix(viper.GetString("repository-url"), "/"),
+		user:     viper.GetString("repository-user"),
+		password: viper.GetString("repository-password"),
+		token:    viper.GetString("repository-token"),
+		client:   http.Client{Timeout: 30 * time.Second},
+	}
+	if r.baseURL == "" {
+		r.baseURL = mavenCentral
+	}
+	if r.token == "" {
+		r.token = os.Getenv(repositoryTokenEnv)
+	}
+	if r.password == "" {
+		r.password = os.Getenv(repositoryPasswordEnv)
+	}
+	return r
+}
+
+func (r repository) get(path string) ([]byte, error) {
+	request, err := http.NewRequest("GET", r.baseURL+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		request.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.user != "" {
+		request.SetBasicAuth(r.user, r.password)
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, errArtifactNotFound
+	case response.StatusCode/100 != 2:
+		return nil, fmt.Errorf("%v answered %v", request.URL.Host, response.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(response.Body, 10<<20))
+}
+
+// artifactPath is the directory of an artifact in the Maven repository
+// layout, e.g. org/apache/velocity/velocity.
+func artifactPath(c Coordinates) string {
+	return strings.Replace(c.group, ".", "/", -1) + "/" + c.artifact
+}
+
+// releases returns the versions of an artifact that are not an alpha, beta,
+// milestone, release candidate or snapshot, newest first, at most
+// releasesPerArtifact of them.
+func (r repository) releases(c Coordinates) ([]string, error) {
+	b, err := r.get(artifactPath(c) + "/maven-metadata.xml")
+	if err != nil {
+		return nil, err
+	}
+	metadata := struct {
+		Versions []string `xml:"versioning>versions>version"`
+	}{}
+	if err := xml.Unmarshal(b, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid maven-metadata.xml of %v: %v", c.artifact, err)
+	}
+	versions := []string{}
+	for _, version := range metadata.Versions {
+		if !isPreRelease(version) {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) > 0 })
+	if len(versions) > releasesPerArtifact {
+		versions = versions[:releasesPerArtifact]
+	}
+	return versions, nil
+}
+
+// latestRelease returns the newest release of an artifact, or an empty
+// string if there is none.
+func (r repository) latestRelease(c Coordinates) (string, error) {
+	versions, err := r.releases(c)
+	if err != nil || len(versions) == 0 {
+		return "", err
+	}
+	return versions[0], nil
+}
+
+// sha1 returns the SHA-1 checksum the repository publishes for the JAR.
+func (r repository) sha1(c Coordinates) (string, error) {
+	fileName := c.artifact + "-" + c.version
+	if c.classifier != "" {
+		fileName += "-" + c.classifier
+	}
+	b, err := r.get(artifactPath(c) + "/" + c.version + "/" + fileName + ".jar.sha1")
+	if err != nil {
+		return "", err
+	}
+	// some repositories append the file name to the checksum
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum of %v", fileName)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+func fileSHA1(filePath string) (string, error) {
+	return fileChecksum(filePath, "sha1", sha1.New)
+}
+
+// checkRepository looks up the kept JARs in the repository: with
+// --check-outdated for newer releases, with --verify-checksums whether the
+// JAR equals the published artifact. JARs without a group are skipped, and
+// artifacts the repository does not know are only logged. When the
+// repository cannot be reached the lookups stop and the check is degraded.
+func checkRepository(keepJars map[string]JarProperties) {
+	outdated, checksums := viper.GetBool("check-outdated"), viper.GetBool("verify-checksums")
+	if !outdated && !checksums {
+		return
+	}
+	r := newRepository()
+	log.Infof("Looking up libraries in %v", r.baseURL)
+
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		jar := keepJars[packageName]
+		if jar.group == "" || isUnidentified(jar) || jar.version == "" {
+			continue
+		}
+		if outdated {
+			latest, err := r.latestRelease(jar.Coordinates)
+			if !repositoryAvailable(jar, err) {
+				return
+			}
+			if err == nil && latest != "" && compareVersions(latest, jar.version) > 0 {
+				log.Infof("%v %v is outdated, the latest release is %v", packageName, jar.version, latest)
+				addFinding(Finding{Type: "outdated", Severity: severityInfo, Package: packageName,
+					Message:      fmt.Sprintf("Kept %v is version %v, the latest release is %v", jar.fileName, jar.version, latest),
+					Files:        []string{jar.filePath},
+					SuggestedFix: &SuggestedFix{Action: "update-module", Target: packageName, Detail: "update to " + latest}})
+			}
+		}
+		if checksums {
+			published, err := r.sha1(jar.Coordinates)
+			if !repositoryAvailable(jar, err) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			actual, err := fileSHA1(jar.filePath)
+			if err != nil {
+				log.Warningf("Unable to hash %v: %v", jar.filePath, err)
+				continue
+			}
+			if actual != published {
+				log.Warningf("%v differs from %v in the repository", jar.fileName, inventoryCoordinates(jar))
+				addFinding(Finding{Type: "checksum-mismatch", Severity: severityWarning, Package: packageName,
+					Message:      fmt.Sprintf("%v differs from %v published in the repository, it may be modified or repackaged", jar.fileName, inventoryCoordinates(jar)),
+					Files:        []string{jar.filePath},
+					SuggestedFix: &SuggestedFix{Action: "replace-file", Target: jar.filePath, Detail: "replace with the artifact from the repository"}})
+			}
+		}
+	}
+}
+
+// repositoryAvailable tells whether lookups can go on after a lookup of jar
+// returned err. Unknown artifacts are fine, other errors degrade the check.
+func repositoryAvailable(jar JarProperties, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, errArtifactNotFound) {
+		log.Debugf("%v is %v", inventoryCoordinates(jar), err)
+		return true
+	}
+	degradeCheck("repository lookups", err.Error())
+	log.Warningf("Repository lookups stopped: %v", err)
+	return false
+}
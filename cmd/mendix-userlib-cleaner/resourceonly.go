@@ -0,0 +1,36 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+// hasClasses tells whether an archive contains at least one class file,
+// including those under META-INF/versions of multi-release JARs.
+func hasClasses(archive *zip.Reader) bool {
+	for _, f := range archive.File {
+		if strings.HasSuffix(f.Name, ".class") && !f.FileInfo().IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeResourceOnly leaves JARs without classes, like icon sets, templates
+// or license bundles, out of deduplication. Their metadata is often missing or
+// made up, so their version says little about which one to keep. Sources and
+// javadoc JARs are paired with their library instead.
+func excludeResourceOnly(jarProp *JarProperties, archive *zip.Reader, logger jarLog) {
+	if isCompanion(*jarProp) || hasClasses(archive) {
+		return
+	}
+	logger.Infof("%v contains no classes, it is resource-only and not deduplicated", jarProp.fileName)
+	jarProp.resourceOnly = true
+	jarProp.unique = jarProp.filePath
+	addFinding(Finding{Type: "resource-only", Severity: severityInfo, Package: jarProp.key(),
+		Message: fmt.Sprintf("%v contains no classes and is left out of deduplication", jarProp.fileName),
+		Files:   []string{jarProp.filePath},
+		SuggestedFix: &SuggestedFix{Action: "review-file", Target: jarProp.filePath,
+			Detail: "check whether the resources are still used, the JAR is never removed by version"}})
+}
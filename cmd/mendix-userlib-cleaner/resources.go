@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// configurationResources are picked up by name from the classpath, so only the
+// first one found is used and the others are silently shadowed.
+var configurationResources = []string{
+	"logback.xml", "logback-test.xml", "log4j.properties", "log4j.xml", "log4j2.xml", "log4j2.properties", "log4j2-test.xml",
+	"reference.conf", "application.conf", "application.properties", "application.yml",
+	"META-INF/spring.factories", "META-INF/spring.handlers", "META-INF/spring.schemas", "META-INF/spring.tooling",
+	"simplelogger.properties", "commons-logging.properties", "jndi.properties", "hibernate.cfg.xml",
+}
+
+// bookkeeping entries every JAR may carry
+var ignoredResources = []string{"license", "license.txt", "license.md", "notice", "notice.txt", "notice.md", "readme", "readme.txt", "readme.md", "about.html", "changelog", "changes", "dependencies", "manifest.mf"}
+
+// isResource tells whether an entry is a resource that can be shadowed by the
+// same entry in another JAR.
+func isResource(name string) bool {
+	if contains(configurationResources, name) {
+		return true
+	}
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".class") || strings.HasPrefix(name, "META-INF/") || contains(ignoredResources, strings.ToLower(path.Base(name))) {
+		return false
+	}
+	return nativePlatform(name) == ""
+}
+
+// checkOverlappingResources reports resources present in more than one kept
+// JAR. Only the first one on the classpath is used, which is a common cause of
+// ignored logging or framework configuration.
+func checkOverlappingResources(jars []JarProperties, keepJars map[string]JarProperties) {
+	providers := make(map[string][]JarProperties)
+	for _, jar := range jars {
+		if keepJars[jar.key()].filePath != jar.filePath {
+			continue
+		}
+		for _, resource := range jar.resources {
+			providers[resource] = append(providers[resource], jar)
+		}
+	}
+	resources := []string{}
+	for resource, providing := range providers {
+		if len(providing) > 1 {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Strings(resources)
+
+	others := 0
+	for _, resource := range resources {
+		fileNames := []string{}
+		filePaths := []string{}
+		for _, jar := range providers[resource] {
+			fileNames = append(fileNames, jar.fileName)
+			filePaths = append(filePaths, jar.filePath)
+		}
+		severity := severityInfo
+		if contains(configurationResources, resource) {
+			severity = severityWarning
+			log.Warningf("%v is provided by several kept JARs, only one is used: %v", resource, strings.Join(fileNames, ", "))
+		} else {
+			log.Debugf("%v is provided by several kept JARs: %v", resource, strings.Join(fileNames, ", "))
+			others++
+		}
+		addFinding(Finding{Type: "resource-overlap", Severity: severity, Package: resource,
+			Message:      fmt.Sprintf("%v is provided by %v", resource, strings.Join(fileNames, ", ")),
+			Files:        filePaths,
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: resource, Detail: "make sure the intended copy comes first on the classpath"}})
+	}
+	if others > 0 {
+		log.Infof("%d other resource(s) are provided by several kept JARs, see --report", others)
+	}
+}
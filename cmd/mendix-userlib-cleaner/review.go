@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	reviewOpen     = "open"
+	reviewResolved = "resolved"
+)
+
+// ReviewQueue tracks unidentified JARs across runs, so the unknowns of a
+// legacy project can be worked off one by one.
+type ReviewQueue struct {
+	Items []ReviewItem `json:"items"`
+}
+
+type ReviewItem struct {
+	SHA256     string `json:"sha256"`
+	FileName   string `json:"fileName"`
+	FilePath   string `json:"filePath"`
+	Size       int64  `json:"size"`
+	FirstSeen  string `json:"firstSeen"`
+	LastSeen   string `json:"lastSeen"`
+	Status     string `json:"status"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+func isUnidentified(jar JarProperties) bool {
+	return jar.group == "" && jar.artifact == jar.filePath
+}
+
+func loadReviewQueue(path string) ReviewQueue {
+	queue := ReviewQueue{Items: []ReviewItem{}}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return queue
+	}
+	if err != nil {
+		fatalf("Unable to read review queue: %v", err)
+	}
+	if err := json.Unmarshal(b, &queue); err != nil {
+		fatalf("Unable to parse review queue %v: %v", path, err)
+	}
+	return queue
+}
+
+// updateReviewQueue adds the unidentified JARs of this run to the queue at
+// path. Open items are resolved when their JAR is identified or no longer
+// present.
+func updateReviewQueue(path string, jars []JarProperties) {
+	queue := loadReviewQueue(path)
+	now := time.Now().Format(time.RFC3339)
+
+	unknown := make(map[string]JarProperties)
+	byPath := make(map[string]JarProperties)
+	for _, jar := range jars {
+		byPath[jar.filePath] = jar
+		if !isUnidentified(jar) || jar.resourceOnly {
+			continue
+		}
+		checksum, err := fileSHA256(jar.filePath)
+		if err != nil {
+			log.Warningf("Unable to hash %v: %v", jar.fileName, err)
+			continue
+		}
+		unknown[checksum] = jar
+	}
+
+	added, resolved := 0, 0
+	for i := range queue.Items {
+		item := &queue.Items[i]
+		if jar, ok := unknown[item.SHA256]; ok {
+			item.FileName, item.FilePath, item.LastSeen = jar.fileName, jar.filePath, now
+			if item.Status != reviewOpen {
+				item.Status, item.Resolution = reviewOpen, ""
+			}
+			delete(unknown, item.SHA256)
+			continue
+		}
+		if item.Status != reviewOpen {
+			continue
+		}
+		jar, ok := byPath[item.FilePath]
+		switch {
+		case ok && !isUnidentified(jar):
+			item.Resolution = "identified as " + jar.key() + " " + jar.version
+		case ok && jar.resourceOnly:
+			item.Resolution = "resource-only, contains no classes"
+		case ok:
+			item.Resolution = "replaced by a different file"
+		case fileExists(item.FilePath):
+			// not part of this run, e.g. because of --module
+			continue
+		default:
+			item.Resolution = "removed"
+		}
+		item.Status = reviewResolved
+		log.Infof("Resolved review item %v: %v", item.FileName, item.Resolution)
+		resolved++
+	}
+
+	checksums := []string{}
+	for checksum := range unknown {
+		checksums = append(checksums, checksum)
+	}
+	sort.Strings(checksums)
+	for _, checksum := range checksums {
+		jar := unknown[checksum]
+		queue.Items = append(queue.Items, ReviewItem{SHA256: checksum, FileName: jar.fileName, FilePath: jar.filePath, Size: jar.size,
+			FirstSeen: now, LastSeen: now, Status: reviewOpen})
+		added++
+	}
+
+	b, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(path, b, 0644); err != nil {
+		fatalf("Unable to write review queue: %v", err)
+	}
+	open := 0
+	for _, item := range queue.Items {
+		if item.Status == reviewOpen {
+			open++
+		}
+	}
+	log.Infof("Review queue %v: %d open, %d added, %d resolved", path, open, added, resolved)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcParams struct {
+	Target string `json:"target"`
+	Mode   string `json:"mode"`
+	Path   string `json:"path"`
+}
+
+// PlannedRemoval is a JAR apply would remove, with its companion files.
+type PlannedRemoval struct {
+	FileName string   `json:"fileName"`
+	Package  string   `json:"package"`
+	Version  string   `json:"version"`
+	KeptFile string   `json:"keptFile,omitempty"`
+	Files    []string `json:"files"`
+}
+
+// rpcServer answers requests from an IDE extension, one JSON object per line
+// on stdin and stdout, until stdin is closed or exit is called. Logs keep
+// going to stderr.
+type rpcServer struct {
+	mode   string
+	policy Policy
+}
+
+func serveStdio(mode string, policy Policy) {
+	server := rpcServer{mode, policy}
+	recoverFatal = true
+	defer func() { recoverFatal = false }()
+	log.Info("Serving JSON-RPC on stdio")
+	encoder := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if isExitRequest(scanner.Bytes()) {
+			return
+		}
+		if response, ok := server.respond(scanner.Bytes()); ok {
+			encoder.Encode(response)
+		}
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		log.Errorf("Unable to read request: %v", err)
+	}
+}
+
+func isExitRequest(b []byte) bool {
+	request := rpcRequest{}
+	return json.Unmarshal(b, &request) == nil && request.Method == "exit"
+}
+
+// respond handles one encoded request. Notifications, requests without an
+// ID, are not answered.
+func (s rpcServer) respond(b []byte) (rpcResponse, bool) {
+	request := rpcRequest{}
+	if err := json.Unmarshal(b, &request); err != nil {
+		return rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, err.Error()}}, true
+	}
+	result, rpcErr := s.handle(request)
+	if request.ID == nil {
+		return rpcResponse{}, false
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: request.ID, Result: result, Error: rpcErr}, true
+}
+
+// handle answers a request. Errors that end a run, like an unreadable
+// m2ee log, only fail the request.
+func (s rpcServer) handle(request rpcRequest) (result interface{}, rpcErr *rpcError) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(fatalError)
+			if !ok {
+				panic(r)
+			}
+			log.Errorf("%v failed: %v", request.Method, err.message)
+			result, rpcErr = nil, &rpcError{rpcServerError, err.message}
+		}
+	}()
+	params := rpcParams{}
+	if len(request.Params) > 0 {
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+	if params.Mode == "" {
+		params.Mode = s.mode
+	}
+	if request.Method == "capabilities" {
+		return capabilities(), nil
+	}
+	resetFindings()
+	resetSkippedFiles()
+	resetDegradedChecks()
+	resetGuards()
+
+	switch request.Method {
+	case "inspect":
+		if _, err := os.Stat(params.Path); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		jar := getJarProps(params.Path, params.Mode, newJarLog())
+		if jar.filePath == "" {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("%v cannot be opened", params.Path)}
+		}
+		return buildReport(filepath.Dir(params.Path), params.Mode, false, []JarProperties{jar}, map[string]JarProperties{}, "package").Jars[0], nil
+	case "scan", "plan", "apply":
+		if info, err := os.Stat(params.Target); err != nil || !info.IsDir() {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("target is not a directory: %v", params.Target)}
+		}
+	default:
+		return nil, &rpcError{rpcMethodNotFound, "unknown method: " + request.Method}
+	}
+
+	if request.Method == "apply" && !checkRunningDeployment(params.Target, true) {
+		return nil, &rpcError{rpcServerError, fmt.Sprintf("the app is running from %v, stop it and apply again", params.Target)}
+	}
+	guardTarget(params.Target)
+	filePaths := listAllFiles(params.Target)
+	jars := listAllJars(filePaths, params.Mode)
+	projectConfig := ""
+	if !viper.GetBool("ignore-project-config") {
+		projectConfig = findProjectConfig(params.Target)
+	}
+	jars, keepJars := analyzeJars(params.Target, projectConfig, params.Mode, s.policy, filePaths, jars)
+
+	switch request.Method {
+	case "plan":
+		plan := []PlannedRemoval{}
+		for _, jar := range jars {
+			if _, guarded := guardReason(jar.filePath); keepJars[jar.key()].filePath != jar.filePath && !guarded {
+				plan = append(plan, PlannedRemoval{jar.fileName, jar.key(), jar.version, keepJars[jar.key()].fileName, jarFiles(jar, filePaths)})
+			}
+		}
+		return plan, nil
+	case "apply":
+		return map[string]int{"removed": cleanJars(true, filePaths, jars, keepJars)}, nil
+	default:
+		cleanJars(false, filePaths, jars, keepJars)
+		return buildReport(params.Target, params.Mode, false, jars, keepJars, "module"), nil
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRPCApplyQuarantinesDuplicates(t *testing.T) {
+	defer viper.Reset()
+	target := t.TempDir()
+	quarantine := t.TempDir()
+	copyFixtureJars(t, target, "checker-qual-2.5.2.jar", "checker-qual-2.5.3.jar")
+	viper.Set("quarantine", quarantine)
+	// another target served before must not stay writable
+	guardTarget(t.TempDir())
+
+	server := rpcServer{mode: "auto"}
+	params, _ := json.Marshal(rpcParams{Target: target})
+	result, rpcErr := server.handle(rpcRequest{Method: "apply", Params: params})
+	if rpcErr != nil {
+		t.Fatalf("apply failed: %v", rpcErr.Message)
+	}
+	if removed := result.(map[string]int)["removed"]; removed != 1 {
+		t.Errorf("apply removed %d file(s), want 1", removed)
+	}
+	if _, err := os.Stat(filepath.Join(target, "checker-qual-2.5.2.jar")); !os.IsNotExist(err) {
+		t.Errorf("checker-qual-2.5.2.jar is still in the target")
+	}
+	matches, _ := filepath.Glob(filepath.Join(quarantine, "*", "checker-qual-2.5.2.jar"))
+	if len(matches) != 1 {
+		t.Errorf("checker-qual-2.5.2.jar was not quarantined, found %v", matches)
+	}
+	if len(mutableRoots) != 2 {
+		t.Errorf("writable roots are %v, want the target and the quarantine", mutableRoots)
+	}
+}
+
+func TestRPCFatalErrorFailsTheRequestOnly(t *testing.T) {
+	defer viper.Reset()
+	recoverFatal = true
+	defer func() { recoverFatal = false }()
+	viper.Set("files", filepath.Join(t.TempDir(), "missing.txt"))
+
+	server := rpcServer{mode: "auto"}
+	params, _ := json.Marshal(rpcParams{Target: t.TempDir()})
+	if _, rpcErr := server.handle(rpcRequest{Method: "scan", Params: params}); rpcErr == nil || rpcErr.Code != rpcServerError {
+		t.Errorf("scan with an unreadable file list = %v, want a server error", rpcErr)
+	}
+	if result, rpcErr := server.handle(rpcRequest{Method: "capabilities"}); rpcErr != nil || result == nil {
+		t.Errorf("capabilities after a failed request = %v, %v", result, rpcErr)
+	}
+}
+
+func TestRPCApplyLeavesRunningDeploymentAlone(t *testing.T) {
+	defer viper.Reset()
+	deployment := filepath.Join(t.TempDir(), "deployment")
+	target := filepath.Join(deployment, "model", "lib", "userlib")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(deployment, "database.lck"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	copyFixtureJars(t, target, "checker-qual-2.5.2.jar", "checker-qual-2.5.3.jar")
+
+	server := rpcServer{mode: "auto"}
+	params, _ := json.Marshal(rpcParams{Target: target})
+	if _, rpcErr := server.handle(rpcRequest{Method: "apply", Params: params}); rpcErr == nil {
+		t.Error("apply in the deployment folder of a running app succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(target, "checker-qual-2.5.2.jar")); err != nil {
+		t.Errorf("checker-qual-2.5.2.jar was removed from the running app: %v", err)
+	}
+}
+
+// copyFixtureJars copies JARs of resources/jars to dir.
+func copyFixtureJars(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join("..", "..", "resources", "jars", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
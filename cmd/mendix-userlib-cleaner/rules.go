@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule documents the check behind a finding type. IDs are stable, new rules
+// are appended and retired ones are not reused.
+type Rule struct {
+	ID          string
+	Type        string
+	Title       string
+	Explanation string
+}
+
+var rules = []Rule{
+	{"MXCLEAN001", "duplicate", "Duplicate JAR",
+		"Several JARs provide the same package. Only the newest version, or the one selected by --keep, the allow-list, constraints or module requirements, is kept. The others are removed with --clean."},
+	{"MXCLEAN002", "unidentified", "Unidentified JAR",
+		"Neither a manifest, pom.properties, pom.xml, Gradle module metadata, module-info.class nor the fingerprint database identifies the JAR, and no class file gives a package name. It cannot be deduplicated and is left alone. Replace it with a JAR containing metadata, and track it with --review-queue meanwhile."},
+	{"MXCLEAN003", "corrupt", "Corrupt JAR",
+		"The file cannot be opened as a ZIP archive. The runtime will fail to load it as well. Replace it with an intact copy."},
+	{"MXCLEAN004", "critical-package", "Critical package",
+		"The package matches --critical-packages, like JDBC drivers or SAML/OIDC libraries. Removing a copy is confirmed interactively, and a downgrade or removing the last version is pointed out."},
+	{"MXCLEAN005", "not-allowed", "Not on the allow-list",
+		"The package is missing from the --allow-list, or its version is outside the approved range. Such JARs are removed with --clean."},
+	{"MXCLEAN006", "vulnerable", "Vulnerable version",
+		"The kept version is affected by a vulnerability listed in --vulnerabilities. If an unaffected duplicate exists, --prefer-non-vulnerable keeps it instead."},
+	{"MXCLEAN007", "constraint-violation", "Version constraint violated",
+		"A version is outside the range given in --constraints. The newest version within the range is kept; if none is present the JAR is kept anyway and has to be replaced."},
+	{"MXCLEAN008", "module-requirement", "Module requirement",
+		"A Marketplace module, according to its RequiredLib marker, does not accept the newest version, so an older one is kept."},
+	{"MXCLEAN009", "module-requirement-conflict", "Conflicting module requirements",
+		"No present version satisfies every module requiring the package. Update the module named in the finding."},
+	{"MXCLEAN010", "fragment-without-host", "Fragment without host",
+		"The host bundle of an OSGi fragment is removed, so the fragment is removed as well."},
+	{"MXCLEAN011", "fragment-host-version", "Fragment host version",
+		"The kept host version is outside the bundle-version range the fragment requires."},
+	{"MXCLEAN012", "module-type-mismatch", "Module type mismatch",
+		"Duplicates differ in being an explicit, automatic or no Java module, which can change behavior on newer Java versions."},
+	{"MXCLEAN013", "native-platform-dropped", "Native platform dropped",
+		"A removed duplicate carries native libraries for a platform the kept JAR does not support."},
+	{"MXCLEAN014", "variant-mismatch", "Variant mismatch",
+		"Duplicates are different Gradle variants, e.g. an API JAR kept in place of the runtime JAR, which may lack the implementation."},
+	{"MXCLEAN015", "service-provider-clash", "Service provider clash",
+		"Several kept JARs register providers for the same service interface, so which one is used depends on the classpath order."},
+	{"MXCLEAN016", "resource-overlap", "Overlapping resource",
+		"Several kept JARs contain the same resource and only the first on the classpath is used. This matters most for configuration files like logback.xml."},
+	{"MXCLEAN017", "class-shadowing", "Class shadowing",
+		"A held or guarded copy of a duplicated package stays and comes first on the classpath, so the app runs on that version rather than the kept one. Remove the copy once it is no longer needed."},
+	{"MXCLEAN018", "mixed-versions", "Mixed versions",
+		"The classes of a package are loaded from several copies at once, which can cause NoSuchMethodError and similar errors at runtime."},
+	{"MXCLEAN019", "mxbuild-discrepancy", "Disagreement with mxbuild",
+		"The duplicate warnings of the log given in --import-mxbuild-log differ from the duplicates found here. Check the metadata of the JARs involved."},
+	{"MXCLEAN020", "packaging-variant", "Packaging variant",
+		"Several packagings of the same version are present, like a plain JAR and a shaded or -jar-with-dependencies JAR. The version comparator sees them as equal, so --packaging-preference decides which one is kept."},
+	{"MXCLEAN021", "lfs-pointer", "Git LFS pointer",
+		"The file is a Git LFS pointer, the JAR itself was never downloaded and the runtime cannot load it. Run git lfs pull in the repository, or pass --lfs-pull, before building or cleaning."},
+	{"MXCLEAN022", "managed-duplicate", "Also a managed dependency",
+		"The JAR is a Mendix 10 managed dependency too, with the same version, according to the Gradle lockfile or verification metadata. Remove it from userlib and let the managed dependency provide it."},
+	{"MXCLEAN023", "managed-conflict", "Conflicts with a managed dependency",
+		"The JAR is a Mendix 10 managed dependency too, but with another version, so two versions end up on the classpath. Remove it from userlib or align the version of the managed dependency in the module settings."},
+	{"MXCLEAN024", "unowned", "No owner",
+		"With --require-owners, every JAR no Marketplace module requires needs an owner and a justification in userlib.owners.yaml, so someone is accountable for keeping it up to date or removing it."},
+	{"MXCLEAN025", "identical-content", "Identical content",
+		"With --content-hash, JARs with the same SHA-256 checksum are duplicates even when their metadata differs or is missing, like a renamed copy. The best identified copy is kept and the others are removed with --clean."},
+	{"MXCLEAN026", "held", "Held duplicate",
+		"The package is on hold with --hold, so its older duplicates stay next to the kept version until the given day, e.g. while a library upgrade is tested. Afterwards they are removed as usual and the hold can be removed from the config."},
+	{"MXCLEAN027", "outdated", "Outdated version",
+		"With --check-outdated, the Maven repository has a newer release of the kept JAR. Pre-releases are not considered. Update the Marketplace module or the JAR after checking the release notes."},
+	{"MXCLEAN028", "checksum-mismatch", "Checksum mismatch",
+		"With --verify-checksums, the kept JAR differs from the artifact with the same coordinates published in the Maven repository. It may have been modified, repackaged or corrupted. Replace it with the published artifact."},
+	{"MXCLEAN029", "sole-provider", "Only provider of a package",
+		"With --guard-dependencies, a JAR that would be removed is the only one providing a package that a kept JAR imports in its manifest or depends on in its pom.xml, e.g. because the kept version of the library dropped the package. It is kept to avoid NoClassDefFoundError. Update the dependent JAR or keep a version providing the package."},
+	{"MXCLEAN030", "shaded", "Shaded JAR",
+		"The JAR bundles several libraries, by the Maven metadata of several artifacts or by unrelated top-level packages. Its metadata only names one of them, so it is left out of deduplication to avoid removing it in favor of, or instead of, one of the libraries. Use --dedup-shaded to deduplicate it anyway."},
+	{"MXCLEAN031", "class-overlap", "Classes in several libraries",
+		"With --check-class-overlap, two JARs that stay after cleaning contain the same classes although their metadata names different libraries, e.g. a fat JAR next to a library it bundles or a repackaged copy. Only the copy that comes first on the classpath is loaded. Keep one of them, or declare an alias if they are the same library."},
+	{"MXCLEAN032", "split-package", "Split package",
+		"With --check-split-packages, the classes of a Java package are spread over several JARs that stay after cleaning. OSGi resolves a package from a single bundle, so such JARs fail to resolve or miss classes, and on the classpath classes present in more than one of them shadow each other. Keep the package in a single JAR."},
+	{"MXCLEAN033", "low-confidence", "Identified with low confidence",
+		"The JAR would be removed as a duplicate, but it was identified with less confidence than --min-confidence, e.g. only by its file name or the package of its classes, so it may be another library. It is kept and reported instead. Check that both JARs are the same library, then remove it by hand or lower --min-confidence."},
+	{"MXCLEAN034", "nested-duplicate", "Nested library also in userlib",
+		"With --nested, a library nested in a JAR, e.g. under lib/ or BOOT-INF/lib/, is also in userlib as a JAR of its own. Two copies, possibly of different versions, end up on the classpath when the wrapper loads its nested JARs. Remove the standalone JAR if the wrapper provides it, or use a build of the wrapper without nested libraries."},
+	{"MXCLEAN035", "java-version", "Compiled for a newer Java",
+		"With --java-version, a kept JAR contains classes compiled for a newer Java than the runtime, so loading them fails with UnsupportedClassVersionError. Use a version of the library that supports the Java version of the Mendix runtime, or upgrade the runtime."},
+	{"MXCLEAN036", "resource-only", "Resource-only JAR",
+		"A JAR contains no classes, only resources like icons, templates or licenses. It is left out of deduplication and never removed by version. Check whether the resources are still used."},
+	{"MXCLEAN037", "symlink", "Symlinked JAR",
+		"With --symlinks report, a JAR in userlib is a symlink, e.g. into a shared library pool. It is analyzed like the file it points to, but never removed. Check that the pool provides the intended version."},
+	{"MXCLEAN038", "case-collision", "File names differing only in case",
+		"Files like Foo.jar and foo.jar cannot coexist on the case-insensitive file systems of Windows and macOS, so a checkout there silently keeps only one of them. Rename or remove all but one."},
+}
+
+// ruleID returns the ID of the rule producing findings of the given type.
+func ruleID(findingType string) string {
+	for _, rule := range rules {
+		if rule.Type == findingType {
+			return rule.ID
+		}
+	}
+	return ""
+}
+
+// explainRule prints the explanation of rules given by ID or finding type,
+// or lists all rules.
+func explainRule(args []string) {
+	if len(args) == 0 {
+		for _, rule := range rules {
+			fmt.Printf("%v  %-28s %v\n", rule.ID, rule.Type, rule.Title)
+		}
+		return
+	}
+	for _, arg := range args {
+		found := false
+		for _, rule := range rules {
+			if strings.EqualFold(arg, rule.ID) || arg == rule.Type {
+				fmt.Printf("%v %v (%v)\n\n%v\n\n", rule.ID, rule.Title, rule.Type, rule.Explanation)
+				found = true
+			}
+		}
+		if !found {
+			fatalf("Unknown rule: %v", arg)
+		}
+	}
+}
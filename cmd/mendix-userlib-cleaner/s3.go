@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of the empty body of the requests sent to
+// S3.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Store reads a bucket of Amazon S3, or of a compatible store like MinIO
+// at AWS_ENDPOINT_URL, with the credentials of the AWS environment variables.
+// Without credentials the requests are anonymous.
+type s3Store struct {
+	bucket       string
+	baseURL      string
+	bucketPath   string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       http.Client
+}
+
+func newS3Store(bucket string) *s3Store {
+	s := &s3Store{
+		bucket:       bucket,
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       http.Client{Timeout: 60 * time.Second},
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	// compatible stores are addressed by path, S3 itself by host
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		s.baseURL = strings.TrimSuffix(endpoint, "/")
+		s.bucketPath = "/" + bucket
+	} else {
+		s.baseURL = fmt.Sprintf("https://%v.s3.%v.amazonaws.com", bucket, s.region)
+	}
+	return s
+}
+
+func (s *s3Store) list(prefix string, recursive bool) ([]remoteObject, error) {
+	objects := []remoteObject{}
+	token := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if !recursive {
+			query["delimiter"] = "/"
+		}
+		if token != "" {
+			query["continuation-token"] = token
+		}
+		b, err := s.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := struct {
+			Contents []struct {
+				Key  string
+				Size int64
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}{}
+		if err := xml.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("invalid listing of %v: %v", s.bucket, err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, remoteObject{key: content.Key, size: content.Size})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *s3Store) read(key string, offset int64, length int64) ([]byte, error) {
+	return s.do("GET", key, nil, map[string]string{"Range": rangeHeader(offset, length)})
+}
+
+func (s *s3Store) open(key string) (io.ReadCloser, error) {
+	return s.send("GET", key, nil, nil)
+}
+
+func (s *s3Store) remove(key string) error {
+	_, err := s.do("DELETE", key, nil, nil)
+	return err
+}
+
+func (s *s3Store) do(method string, key string, query map[string]string, headers map[string]string) ([]byte, error) {
+	body, err := s.send(method, key, query, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// send sends a request and returns the body of a successful response.
+func (s *s3Store) send(method string, key string, query map[string]string, headers map[string]string) (io.ReadCloser, error) {
+	path := s3Escape(s.bucketPath+"/"+key, true)
+	rawQuery := canonicalQuery(query)
+	url := s.baseURL + path
+	if rawQuery != "" {
+		url += "?" + rawQuery
+	}
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	s.sign(request, path, rawQuery, time.Now())
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode/100 != 2 {
+		response.Body.Close()
+		return nil, fmt.Errorf("%v answered %v", request.URL.Host, response.Status)
+	}
+	return response.Body, nil
+}
+
+// sign adds the AWS Signature Version 4 of a request without a body.
+func (s *s3Store) sign(request *http.Request, path string, rawQuery string, now time.Time) {
+	if s.accessKey == "" {
+		return
+	}
+	now = now.UTC()
+	date := now.Format("20060102")
+	request.Header.Set("x-amz-date", now.Format("20060102T150405Z"))
+	request.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	if s.sessionToken != "" {
+		request.Header.Set("x-amz-security-token", s.sessionToken)
+	}
+
+	names := []string{"host"}
+	for name := range request.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		value := request.URL.Host
+		if name != "host" {
+			value = request.Header.Get(name)
+		}
+		canonicalHeaders += name + ":" + strings.TrimSpace(value) + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{request.Method, path, rawQuery, canonicalHeaders, signedHeaders, emptyPayloadHash}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + request.Header.Get("x-amz-date") + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		s.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, data)
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes a query sorted by name, as signed.
+func canonicalQuery(query map[string]string) string {
+	names := []string{}
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		parts = append(parts, s3Escape(name, false)+"="+s3Escape(query[name], false))
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and slashes
+// in paths.
+func s3Escape(value string, isPath bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~', isPath && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func rangeHeader(offset int64, length int64) string {
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
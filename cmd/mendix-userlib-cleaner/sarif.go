@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// SARIF 2.1.0, the subset code scanning tools like GitHub read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+var sarifLevels = map[string]string{severityCritical: "error", severityWarning: "warning", severityInfo: "note"}
+
+type sarifExporter struct{}
+
+func (sarifExporter) Name() string { return "sarif" }
+
+func (sarifExporter) Export(run ExportRun) error {
+	driver := sarifDriver{Name: "mendix-userlib-cleaner", InformationURI: "https://github.com/cinaq/mendix-userlib-cleaner", Rules: []sarifRule{}}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{rule.ID, rule.Type, sarifMessage{rule.Title}, sarifMessage{rule.Explanation}})
+	}
+	results := []sarifResult{}
+	for _, finding := range run.Report.Findings {
+		result := sarifResult{RuleID: finding.Rule, Level: sarifLevels[finding.Severity], Message: sarifMessage{finding.Message}}
+		for _, file := range finding.Files {
+			location := sarifLocation{}
+			location.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(file)
+			result.Locations = append(result.Locations, location)
+		}
+		results = append(results, result)
+	}
+
+	b, err := json.MarshalIndent(sarifLog{"https://json.schemastore.org/sarif-2.1.0.json", "2.1.0", []sarifRun{{sarifTool{driver}, results}}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := exportPath(".sarif")
+	if err := writeFileAtomic(path, b, 0644); err != nil {
+		return err
+	}
+	log.Infof("SARIF report written to %v", path)
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const servicesPrefix = "META-INF/services/"
+
+// parseServiceProviders returns the provider classes listed in a
+// META-INF/services file, without comments and blank lines.
+func parseServiceProviders(text string) []string {
+	providers := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			providers = append(providers, line)
+		}
+	}
+	return providers
+}
+
+// checkServiceProviders warns when several kept JARs register providers for
+// the same service interface. Which one the ServiceLoader or a factory like
+// JAXP picks depends on the classpath order, so removing duplicates does not
+// fix it.
+func checkServiceProviders(jars []JarProperties, keepJars map[string]JarProperties) {
+	registrations := make(map[string][]JarProperties)
+	for _, jar := range jars {
+		if keepJars[jar.key()].filePath != jar.filePath {
+			continue
+		}
+		for service := range jar.services {
+			registrations[service] = append(registrations[service], jar)
+		}
+	}
+	services := []string{}
+	for service, registering := range registrations {
+		if len(registering) > 1 {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		fileNames := []string{}
+		filePaths := []string{}
+		providers := []string{}
+		for _, jar := range registrations[service] {
+			fileNames = append(fileNames, jar.fileName)
+			filePaths = append(filePaths, jar.filePath)
+			providers = append(providers, jar.services[service]...)
+		}
+		log.Warningf("Providers for %v are registered by %v: %v", service, strings.Join(fileNames, ", "), strings.Join(providers, ", "))
+		addFinding(Finding{Type: "service-provider-clash", Severity: severityWarning, Package: service,
+			Message:      fmt.Sprintf("%v register providers for %v: %v", strings.Join(fileNames, ", "), service, strings.Join(providers, ", ")),
+			Files:        filePaths,
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: servicesPrefix + service, Detail: "make sure the intended provider is used, e.g. by a system property"}})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// shadedLibraries returns the libraries bundled in a shaded or uber JAR: the
+// group:artifact of the Maven metadata of several artifacts under
+// META-INF/maven. Regular JARs return nothing. The packages of the classes
+// are not used, since a single library like JUnit often spans several, like
+// junit.framework and org.junit.
+func shadedLibraries(archive *zip.Reader) []string {
+	artifacts := []string{}
+	for _, f := range archive.File {
+		if !strings.HasPrefix(f.Name, "META-INF/maven/") || (path.Base(f.Name) != "pom.properties" && path.Base(f.Name) != "pom.xml") {
+			continue
+		}
+		parts := strings.Split(f.Name, "/")
+		if len(parts) == 5 && !contains(artifacts, parts[2]+":"+parts[3]) {
+			artifacts = append(artifacts, parts[2]+":"+parts[3])
+		}
+	}
+	if len(artifacts) < 2 {
+		return nil
+	}
+	sort.Strings(artifacts)
+	return artifacts
+}
+
+// excludeShaded leaves a shaded JAR out of deduplication, since its
+// metadata or classes only name one of the libraries it bundles, unless
+// --dedup-shaded is given.
+func excludeShaded(jarProp *JarProperties, logger jarLog) {
+	if len(jarProp.shaded) == 0 || viper.GetBool("dedup-shaded") {
+		return
+	}
+	logger.Infof("%v is shaded, bundling %v, it is not deduplicated", jarProp.fileName, strings.Join(jarProp.shaded, ", "))
+	jarProp.unique = jarProp.filePath
+	addFinding(Finding{Type: "shaded", Severity: severityInfo, Package: jarProp.key(),
+		Message: fmt.Sprintf("%v bundles %d libraries (%v) and is left out of deduplication", jarProp.fileName, len(jarProp.shaded), strings.Join(jarProp.shaded, ", ")),
+		Files:   []string{jarProp.filePath},
+		SuggestedFix: &SuggestedFix{Action: "review-file", Target: jarProp.filePath,
+			Detail: "check that the bundled libraries do not clash with other JARs, or pass --dedup-shaded"}})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestShadedLibrariesOfResources(t *testing.T) {
+	files, err := filepath.Glob("../../resources/jars/*.jar")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no JARs in resources/jars: %v", err)
+	}
+	for _, filePath := range files {
+		archive, err := zip.OpenReader(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shaded := shadedLibraries(&archive.Reader); shaded != nil {
+			t.Errorf("%v is not shaded, got %v", filepath.Base(filePath), shaded)
+		}
+		archive.Close()
+	}
+}
+
+func TestShadedLibraries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    []string
+	}{
+		{"single artifact", []string{"META-INF/maven/junit/junit/pom.properties", "junit/framework/Test.class", "org/junit/Test.class", "org/hamcrest/Matcher.class"}, nil},
+		{"unrelated packages", []string{"org/apache/A.class", "com/google/B.class", "io/netty/C.class"}, nil},
+		{"several artifacts", []string{"META-INF/maven/com.acme/app/pom.properties", "META-INF/maven/com.google.guava/guava/pom.xml", "META-INF/maven/com.google.guava/guava/pom.properties"},
+			[]string{"com.acme:app", "com.google.guava:guava"}},
+	}
+	for _, test := range tests {
+		got := shadedLibraries(testArchive(t, test.entries))
+		if len(got) != len(test.want) {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	}
+}
+
+// testArchive returns a ZIP with empty entries of the given names.
+func testArchive(t *testing.T, names []string) *zip.Reader {
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	for _, name := range names {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// SharedLibraries are the JARs found byte-identical in the userlib of
+// several projects, like the apps deployed on one server.
+type SharedLibraries struct {
+	Projects []string `json:"projects"`
+	// Size is what the shared JARs take in all projects, Reclaimable what
+	// is saved by keeping a single copy of each
+	Size        int64           `json:"size"`
+	Reclaimable int64           `json:"reclaimable"`
+	Libraries   []SharedLibrary `json:"libraries"`
+}
+
+type SharedLibrary struct {
+	SHA256      string   `json:"sha256"`
+	Coordinates string   `json:"coordinates"`
+	Size        int64    `json:"size"`
+	Projects    []string `json:"projects"`
+	Files       []string `json:"files"`
+	Reclaimable int64    `json:"reclaimable"`
+}
+
+// sharedLibraries implements the shared-libs command. It hashes the JARs in
+// each of the targetDirs and reports the ones present in more than one,
+// with the storage a shared library directory would save, to stdout or as
+// JSON to reportPath.
+func sharedLibraries(targetDirs []string, mode string, reportPath string) {
+	if len(targetDirs) < 2 {
+		fatal("Pass the userlib directories of at least two projects as arguments")
+	}
+
+	shared := SharedLibraries{Projects: []string{}, Libraries: []SharedLibrary{}}
+	byHash := make(map[string]*SharedLibrary)
+	for _, targetDir := range targetDirs {
+		project := projectName(targetDir, findProjectConfig(targetDir))
+		if contains(shared.Projects, project) {
+			project = targetDir
+		}
+		shared.Projects = append(shared.Projects, project)
+		for _, jar := range listAllJars(listAllFiles(targetDir), mode) {
+			hash, err := fileSHA256(jar.filePath)
+			if err != nil {
+				log.Warningf("Unable to hash %v: %v", jar.filePath, err)
+				continue
+			}
+			library, ok := byHash[hash]
+			if !ok {
+				library = &SharedLibrary{SHA256: hash, Coordinates: jar.fileName, Size: jar.size}
+				byHash[hash] = library
+			}
+			if library.Coordinates == jar.fileName && !isUnidentified(jar) {
+				library.Coordinates = inventoryCoordinates(jar)
+			}
+			if !contains(library.Projects, project) {
+				library.Projects = append(library.Projects, project)
+			}
+			library.Files = append(library.Files, jar.filePath)
+		}
+	}
+
+	for _, library := range byHash {
+		if len(library.Projects) < 2 {
+			continue
+		}
+		library.Reclaimable = library.Size * int64(len(library.Files)-1)
+		shared.Size += library.Size * int64(len(library.Files))
+		shared.Reclaimable += library.Reclaimable
+		shared.Libraries = append(shared.Libraries, *library)
+	}
+	sort.Slice(shared.Libraries, func(i, j int) bool {
+		a, b := shared.Libraries[i], shared.Libraries[j]
+		if a.Reclaimable != b.Reclaimable {
+			return a.Reclaimable > b.Reclaimable
+		}
+		return a.Coordinates < b.Coordinates
+	})
+
+	log.Infof("%d JAR(s) are identical in several of %d projects, %v in total, %v reclaimable with a single copy each",
+		len(shared.Libraries), len(shared.Projects), formatBytes(shared.Size), formatBytes(shared.Reclaimable))
+	if reportPath == "" {
+		for _, library := range shared.Libraries {
+			fmt.Fprintf(os.Stdout, "%v  %d project(s)  %v each  %v reclaimable\n", library.Coordinates, len(library.Projects), formatBytes(library.Size), formatBytes(library.Reclaimable))
+		}
+		return
+	}
+	b, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := writeFileAtomic(reportPath, b, 0644); err != nil {
+		fatalf("Unable to write report: %v", err)
+	}
+	log.Infof("Wrote shared libraries to %v", reportPath)
+}
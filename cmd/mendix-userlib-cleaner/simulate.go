@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// simulate overlays hypothetical additions and removals on the files in
+// targetDir and reports how the keep decisions change. Nothing is written.
+func simulate(targetDir string, mode string, add []string, remove []string, policy Policy) {
+	if len(add) == 0 && len(remove) == 0 {
+		fatal("Nothing to simulate, use --add and/or --remove")
+	}
+
+	guardTarget(targetDir)
+	filePaths := listAllFiles(targetDir)
+	log.Info("Computing current state")
+	currentJars := listAllJars(filePaths, mode)
+	currentKeep := decideJarsToKeep(currentJars, mode, policy)
+
+	simulatedPaths := []string{}
+	for _, filePath := range filePaths {
+		if contains(remove, filepath.Base(filePath)) || contains(remove, filePath) {
+			log.Infof("Simulating removal of %v", filePath)
+			continue
+		}
+		simulatedPaths = append(simulatedPaths, filePath)
+	}
+	for _, filePath := range add {
+		if _, err := os.Stat(filePath); err != nil {
+			fatalf("Unable to add %v: %v", filePath, err)
+		}
+		log.Infof("Simulating addition of %v", filePath)
+		simulatedPaths = append(simulatedPaths, filePath)
+	}
+
+	log.Info("Computing simulated state")
+	simulatedJars := listAllJars(simulatedPaths, mode)
+	simulatedKeep := decideJarsToKeep(simulatedJars, mode, policy)
+
+	packageNames := []string{}
+	for packageName := range currentKeep {
+		packageNames = append(packageNames, packageName)
+	}
+	for packageName := range simulatedKeep {
+		if _, ok := currentKeep[packageName]; !ok {
+			packageNames = append(packageNames, packageName)
+		}
+	}
+	sort.Strings(packageNames)
+
+	changes := 0
+	for _, packageName := range packageNames {
+		before, hadBefore := currentKeep[packageName]
+		after, hasAfter := simulatedKeep[packageName]
+		switch {
+		case !hadBefore:
+			log.Infof("New package %v: %v", packageName, after.fileName)
+		case !hasAfter:
+			log.Warningf("Package %v would disappear, currently provided by %v", packageName, before.fileName)
+		case before.filePath != after.filePath:
+			log.Warningf("Package %v would switch from %v to %v", packageName, before.fileName, after.fileName)
+		default:
+			continue
+		}
+		changes++
+	}
+	log.Infof("Simulated changes affect %d package(s)", changes)
+
+	count := cleanJars(false, simulatedPaths, simulatedJars, simulatedKeep)
+	log.Infof("After the simulated changes a clean would remove: %d files", count)
+}
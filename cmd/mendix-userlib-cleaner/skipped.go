@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+type skippedFile struct {
+	filePath string
+	reason   string
+}
+
+var (
+	skippedMutex sync.Mutex
+	skippedFiles = make(map[string]skippedFile)
+)
+
+// skipFile records that a file in the target was not processed and why.
+func skipFile(filePath string, reason string) {
+	skippedMutex.Lock()
+	defer skippedMutex.Unlock()
+	log.Debugf("Skipping %v: %v", filePath, reason)
+	skippedFiles[filePath] = skippedFile{filePath: filePath, reason: reason}
+}
+
+// unskipFile records that a skipped file is processed after all.
+func unskipFile(filePath string) {
+	skippedMutex.Lock()
+	defer skippedMutex.Unlock()
+	delete(skippedFiles, filePath)
+}
+
+func resetSkippedFiles() {
+	skippedMutex.Lock()
+	defer skippedMutex.Unlock()
+	skippedFiles = make(map[string]skippedFile)
+}
+
+func reportSkippedFiles(show bool) {
+	skippedMutex.Lock()
+	defer skippedMutex.Unlock()
+	if len(skippedFiles) == 0 {
+		return
+	}
+	if !show {
+		log.Infof("Skipped %d file(s), use --show-skipped to list them", len(skippedFiles))
+		return
+	}
+
+	skipped := []skippedFile{}
+	for _, f := range skippedFiles {
+		skipped = append(skipped, f)
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].filePath < skipped[j].filePath
+	})
+	log.Infof("Skipped %d file(s):", len(skipped))
+	for _, f := range skipped {
+		log.Infof("  %v: %v", reportPath(f.filePath), f.reason)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// runSummary counts the findings of a run.
+type runSummary struct {
+	critical    int
+	warnings    int
+	removals    int
+	unknown     int
+	vulnerable  int
+	reclaimable int64
+}
+
+func summarize(jars []JarProperties) runSummary {
+	sizes := make(map[string]int64)
+	for _, jar := range jars {
+		sizes[jar.filePath] = jar.size
+	}
+
+	summary := runSummary{}
+	for _, finding := range allFindings() {
+		switch finding.Severity {
+		case severityCritical:
+			summary.critical++
+		case severityWarning:
+			summary.warnings++
+		}
+		switch finding.Type {
+		case "duplicate", "identical-content":
+			summary.removals++
+			for _, filePath := range finding.Files {
+				summary.reclaimable += sizes[filePath]
+			}
+		case "unidentified":
+			summary.unknown++
+		case "vulnerable":
+			summary.vulnerable++
+		}
+	}
+	return summary
+}
+
+// add sums up the summaries of several targets.
+func (summary *runSummary) add(other runSummary) {
+	summary.critical += other.critical
+	summary.warnings += other.warnings
+	summary.removals += other.removals
+	summary.unknown += other.unknown
+	summary.vulnerable += other.vulnerable
+	summary.reclaimable += other.reclaimable
+}
+
+// printSummary ends a run with a prioritized overview of the findings and
+// the next steps to take. With several --target, target names the one
+// summarized, or is empty for the aggregate of all.
+func printSummary(clean bool, count int, summary runSummary, checks []DegradedCheck, target string) {
+	if target != "" {
+		log.Infof("Summary of %v: %d critical, %d warning(s), %d safe removal(s) (%v), %d unknown JAR(s)", target, summary.critical, summary.warnings, summary.removals, formatBytes(summary.reclaimable), summary.unknown)
+	} else {
+		log.Infof("Summary: %d critical, %d warning(s), %d safe removal(s) (%v), %d unknown JAR(s)", summary.critical, summary.warnings, summary.removals, formatBytes(summary.reclaimable), summary.unknown)
+	}
+	if clean {
+		log.Infof("Total files removed: %d", count)
+	} else {
+		log.Infof("Would have removed: %d files", count)
+	}
+
+	steps := []string{}
+	if summary.critical > 0 {
+		steps = append(steps, fmt.Sprintf("Fix the %d critical issue(s) first, e.g. replace corrupt JARs", summary.critical))
+	}
+	if summary.warnings > 0 {
+		steps = append(steps, fmt.Sprintf("Review the %d warning(s) above, use --report for suggested fixes", summary.warnings))
+	}
+	if summary.removals > 0 && !clean {
+		steps = append(steps, fmt.Sprintf("Run with --clean to remove %d duplicate JAR(s) and reclaim %v", summary.removals, formatBytes(summary.reclaimable)))
+	}
+	if summary.unknown > 0 {
+		steps = append(steps, fmt.Sprintf("Identify the %d unknown JAR(s), they cannot be deduplicated reliably, e.g. with the triage command", summary.unknown))
+	}
+	if len(checks) > 0 {
+		log.Warning("Degraded checks, these analyses were skipped or incomplete:")
+	}
+	for _, check := range checks {
+		log.Warningf("  %v: %v", check.Check, strings.Join(check.Reasons, "; "))
+	}
+	if len(steps) > 0 {
+		log.Info("Next steps:")
+	}
+	for i, step := range steps {
+		log.Infof("  %d. %s", i+1, step)
+	}
+}
+
+// printResultLine writes the summary of a run as a single line of key=value
+// pairs, the only output on stdout, for scripts to pick up without parsing
+// the log:
+//
+//	result critical=0 warnings=1 removals=3 reclaimable=1048576 unknown=0 vulnerable=0 removed=3 degraded=0 clean=true
+//
+// With several --target it is the aggregate of all.
+func printResultLine(clean bool, count int, summary runSummary, degraded int) {
+	fmt.Printf("result critical=%d warnings=%d removals=%d reclaimable=%d unknown=%d vulnerable=%d removed=%d degraded=%d clean=%t\n",
+		summary.critical, summary.warnings, summary.removals, summary.reclaimable, summary.unknown, summary.vulnerable, count, degraded, clean)
+}
+
+// printErrorResultLine is the result line of a run ending with an error, with
+// the message quoted, e.g. result error="Unable to read lockfile".
+func printErrorResultLine(message string) {
+	if viper.GetBool("stdio") {
+		// stdout carries the protocol
+		return
+	}
+	fmt.Printf("result error=%q\n", message)
+}
+
+// Exit codes, a run ending with findings at or above --fail-on exits with
+// the code of the most severe one. Errors exit with 1.
+const (
+	exitCritical   = 2
+	exitWarning    = 3
+	exitDuplicates = 4
+)
+
+var failOnLevels = []string{"none", "critical", "warning", "duplicate"}
+
+func checkFailOn(failOn string) {
+	if !contains(failOnLevels, failOn) {
+		fatalf("Unsupported --fail-on: %v", failOn)
+	}
+}
+
+// exitCode returns the exit code of a run. Duplicates only count when they
+// are left behind, i.e. without --clean.
+func exitCode(failOn string, clean bool, summary runSummary) int {
+	threshold := 0
+	for i, level := range failOnLevels {
+		if level == failOn {
+			threshold = i
+		}
+	}
+	switch {
+	case threshold >= 1 && summary.critical > 0:
+		return exitCritical
+	case threshold >= 2 && summary.warnings > 0:
+		return exitWarning
+	case threshold >= 3 && summary.removals > 0 && !clean:
+		return exitDuplicates
+	}
+	return 0
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// captureStdout returns what f writes to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestResultLines(t *testing.T) {
+	summary := runSummary{warnings: 1, removals: 3, reclaimable: 1048576}
+	want := "result critical=0 warnings=1 removals=3 reclaimable=1048576 unknown=0 vulnerable=0 removed=3 degraded=0 clean=true\n"
+	if got := captureStdout(t, func() { printResultLine(true, 3, summary, 0) }); got != want {
+		t.Errorf("printResultLine() = %q, want %q", got, want)
+	}
+	want = "result error=\"open /tmp/my apps/userlib: no such file or directory\"\n"
+	if got := captureStdout(t, func() { printErrorResultLine("open /tmp/my apps/userlib: no such file or directory") }); got != want {
+		t.Errorf("printErrorResultLine() = %q, want %q", got, want)
+	}
+}
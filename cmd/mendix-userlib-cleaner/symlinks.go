@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Symlink policies: follow analyzes links like the files they point to,
+// skip leaves them out and report analyzes and reports them, but never
+// removes them.
+const (
+	symlinksFollow = "follow"
+	symlinksSkip   = "skip"
+	symlinksReport = "report"
+)
+
+var symlinkPolicy = symlinksFollow
+
+func setSymlinkPolicy(value string) {
+	if !contains([]string{symlinksFollow, symlinksSkip, symlinksReport}, value) {
+		fatalf("Unsupported --symlinks: %v", value)
+	}
+	symlinkPolicy = value
+}
+
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// resolveSymlink returns what a link found while listing points to, or false
+// if it is skipped by the policy or broken.
+func resolveSymlink(filePath string) (os.FileInfo, bool) {
+	if symlinkPolicy == symlinksSkip {
+		skipFile(filePath, "symlink")
+		return nil, false
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		log.Warningf("Broken symlink %v: %v", filePath, err)
+		skipFile(filePath, fmt.Sprintf("broken symlink: %v", err))
+		return nil, false
+	}
+	return info, true
+}
+
+// checkSymlinks keeps the files symlinks in userlib point to, since removing
+// them breaks the links, while a link is removed without touching what it
+// points to. With --symlinks report, the links are reported and kept too.
+func checkSymlinks(jars []JarProperties) {
+	byPath := make(map[string]JarProperties)
+	for _, jar := range jars {
+		if resolved, err := filepath.EvalSymlinks(jar.filePath); err == nil {
+			if info, err := os.Lstat(jar.filePath); err == nil && !isSymlink(info) {
+				byPath[resolved] = jar
+			}
+		}
+	}
+	for _, jar := range jars {
+		info, err := os.Lstat(jar.filePath)
+		if err != nil || !isSymlink(info) {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(jar.filePath)
+		if err != nil {
+			continue
+		}
+		if target, ok := byPath[resolved]; ok {
+			log.Debugf("Keeping %v, %v links to it", target.fileName, jar.fileName)
+			guardFile(target.filePath, "target of symlink "+jar.fileName)
+		}
+		if symlinkPolicy != symlinksReport {
+			continue
+		}
+		log.Infof("%v is a symlink to %v", jar.fileName, resolved)
+		guardFile(jar.filePath, "symlink")
+		addFinding(Finding{Type: "symlink", Severity: severityInfo, Package: jar.key(),
+			Message: fmt.Sprintf("%v is a symlink to %v and is never removed", jar.fileName, resolved),
+			Files:   []string{jar.filePath},
+			SuggestedFix: &SuggestedFix{Action: "review-file", Target: jar.filePath,
+				Detail: "check that the shared library pool provides the intended version"}})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// targetOutputFlags are the files written for each target when several are
+// cleaned in one run. Each target gets its own, named after it, e.g.
+// report-app1.json. --metrics-csv has a project column and is shared.
+var targetOutputFlags = []string{"report", "review-queue", "changelog", "history", "repackage"}
+
+var unsafeLabel = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// projectLibDirs are the directories of a Mendix project holding JARs,
+// relative to the project root with the .mpr file.
+var projectLibDirs = []string{"userlib", "vendorlib", "deployment/model/lib"}
+
+// isProjectRoot tells whether dir has a .mpr file. The directory is read
+// rather than globbed, since extended-length paths like \\?\C:\ contain a
+// wildcard.
+func isProjectRoot(dir string) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".mpr") {
+			return true
+		}
+	}
+	return false
+}
+
+// findProjectRoot walks up from dir and returns the first directory with a
+// .mpr file, or an empty string.
+func findProjectRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if isProjectRoot(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// discoverTargets replaces targets that are the root of a Mendix project,
+// with its .mpr file, by the directories of the project holding JARs.
+func discoverTargets(targets []string) []string {
+	discovered := []string{}
+	for _, targetDir := range targets {
+		if !isProjectRoot(targetDir) {
+			discovered = append(discovered, targetDir)
+			continue
+		}
+		found := []string{}
+		for _, name := range projectLibDirs {
+			dir := filepath.Join(targetDir, filepath.FromSlash(name))
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				found = append(found, dir)
+			}
+		}
+		if len(found) == 0 {
+			fatalf("%v is a Mendix project without %v", targetDir, strings.Join(projectLibDirs, ", "))
+		}
+		log.Infof("%v is a Mendix project, cleaning %v", targetDir, strings.Join(found, ", "))
+		discovered = append(discovered, found...)
+	}
+	return discovered
+}
+
+// sameProject tells whether all targets are in the same project, whose
+// settings then apply to all.
+func sameProject(targets []string) bool {
+	for _, targetDir := range targets[1:] {
+		if projectDir(targetDir, findProjectConfig(targetDir)) != projectDir(targets[0], findProjectConfig(targets[0])) {
+			return false
+		}
+	}
+	return true
+}
+
+// targetLabels names each target by its project, and by its directory in
+// the project, like app-vendorlib, if there are several of the same project.
+// Projects of the same name are numbered, e.g. app, app-2.
+func targetLabels(targets []string) []string {
+	names := make([]string, len(targets))
+	projects := make(map[string]int)
+	for i, targetDir := range targets {
+		names[i] = projectName(targetDir, findProjectConfig(targetDir))
+		projects[names[i]]++
+	}
+	labels := make([]string, len(targets))
+	seen := make(map[string]int)
+	for i, targetDir := range targets {
+		label := names[i]
+		abs, err := filepath.Abs(targetDir)
+		if root := findProjectRoot(targetDir); projects[names[i]] > 1 && root != "" && err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != "." {
+				label += "-" + rel
+			}
+		}
+		label = unsafeLabel.ReplaceAllString(label, "-")
+		seen[label]++
+		if seen[label] > 1 {
+			label = fmt.Sprintf("%v-%d", label, seen[label])
+		}
+		labels[i] = label
+	}
+	return labels
+}
+
+// targetOutputs returns the output files as given, before they are named
+// after the targets.
+func targetOutputs() map[string]string {
+	outputs := make(map[string]string)
+	for _, name := range targetOutputFlags {
+		outputs[name] = viper.GetString(name)
+	}
+	return outputs
+}
+
+// withLabel inserts the label of a target before the extension of a path.
+func withLabel(path string, label string) string {
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + "-" + label + extension
+}
+
+// useTarget prepares the next of several targets: it starts over with no
+// findings and names the output files after the target.
+func useTarget(targetDir string, label string, outputs map[string]string) {
+	resetTarget()
+	viper.Set("target", targetDir)
+	targetLabel = label
+	for name, path := range outputs {
+		if path != "" {
+			viper.Set(name, withLabel(path, label))
+		}
+	}
+}
+
+// resetTarget forgets what was found in the previous analysis of a target.
+func resetTarget() {
+	resetFindings()
+	resetSkippedFiles()
+	resetDegradedChecks()
+	resetGuards()
+	disposedBytes = 0
+	// the triage file is the one of the project
+	triageOnce = sync.Once{}
+}
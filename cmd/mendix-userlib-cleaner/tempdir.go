@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	tempDirMutex sync.Mutex
+	runTempDir   string
+)
+
+// tempDir returns the temporary directory of this run, creating it on first
+// use below --tmp-dir or the system default.
+func tempDir() string {
+	tempDirMutex.Lock()
+	defer tempDirMutex.Unlock()
+	if runTempDir == "" {
+		dir, err := ioutil.TempDir(viper.GetString("tmp-dir"), "mendix-userlib-cleaner-")
+		if err != nil {
+			printErrorResultLine(fmt.Sprintf("Unable to create temporary directory: %v", err))
+			log.Fatalf("Unable to create temporary directory: %v", err)
+		}
+		log.Debugf("Using temporary directory %v", dir)
+		runTempDir = dir
+	}
+	return runTempDir
+}
+
+func removeTempDir() {
+	tempDirMutex.Lock()
+	defer tempDirMutex.Unlock()
+	if runTempDir == "" {
+		return
+	}
+	if err := os.RemoveAll(runTempDir); err != nil {
+		log.Warningf("Unable to remove temporary directory %v: %v", runTempDir, err)
+	}
+	runTempDir = ""
+}
+
+// removeTempDirOnSignal makes sure an interrupted run leaves nothing behind.
+func removeTempDirOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Warningf("Received %v, cleaning up", sig)
+		printErrorResultLine(fmt.Sprintf("interrupted by %v", sig))
+		removeTempDir()
+		os.Exit(130)
+	}()
+}
+
+// fatalError is raised by fatal while serving JSON-RPC, so a request that
+// cannot be handled is answered with an error rather than ending the server.
+type fatalError struct {
+	message string
+}
+
+// recoverFatal makes fatal raise a fatalError instead of exiting.
+var recoverFatal bool
+
+// fatal and fatalf replace log.Fatal so the temporary directory is removed
+// before the process exits.
+func fatal(args ...interface{}) {
+	if recoverFatal {
+		panic(fatalError{fmt.Sprint(args...)})
+	}
+	printErrorResultLine(fmt.Sprint(args...))
+	removeTempDir()
+	log.Fatal(args...)
+}
+
+func fatalf(format string, args ...interface{}) {
+	if recoverFatal {
+		panic(fatalError{fmt.Sprintf(format, args...)})
+	}
+	printErrorResultLine(fmt.Sprintf(format, args...))
+	removeTempDir()
+	log.Fatalf(format, args...)
+}
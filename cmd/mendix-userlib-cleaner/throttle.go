@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// jarArchive is an opened JAR whose reads count against --io-throttle.
+type jarArchive struct {
+	*zip.Reader
+	file *os.File
+}
+
+func (a jarArchive) Close() error {
+	return a.file.Close()
+}
+
+func openJar(filePath string) (jarArchive, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return jarArchive{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return jarArchive{}, err
+	}
+	reader, err := zip.NewReader(throttledReaderAt{file}, info.Size())
+	if err != nil {
+		file.Close()
+		return jarArchive{}, err
+	}
+	return jarArchive{reader, file}, nil
+}
+
+type throttledReaderAt struct {
+	reader io.ReaderAt
+}
+
+func (r throttledReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.reader.ReadAt(p, off)
+	throttleIO(n)
+	return n, err
+}
+
+var ioThrottle struct {
+	sync.Mutex
+	rate  int64
+	start time.Time
+	bytes int64
+}
+
+func setIOThrottle(rate int64) {
+	if rate < 0 {
+		fatalf("Invalid --io-throttle value %v, expected bytes per second or 0", rate)
+	}
+	ioThrottle.rate = rate
+}
+
+// throttleIO accounts for bytes read by all jobs together and sleeps as long
+// as the average rate since the first read exceeds --io-throttle.
+func throttleIO(n int) {
+	rate := ioThrottle.rate
+	if rate <= 0 || n <= 0 {
+		return
+	}
+	ioThrottle.Lock()
+	if ioThrottle.start.IsZero() {
+		ioThrottle.start = time.Now()
+	}
+	ioThrottle.bytes += int64(n)
+	wait := time.Duration(float64(ioThrottle.bytes)/float64(rate)*float64(time.Second)) - time.Since(ioThrottle.start)
+	ioThrottle.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// triageFileName is the sidecar file next to userlib that is used without
+// --triage.
+const triageFileName = "userlib.triage.yaml"
+
+// triageSampleEntries is the number of entries shown for each JAR in triage.
+const triageSampleEntries = 8
+
+// TriageEntry is the coordinates assigned to an unidentified JAR in triage,
+// by its SHA-256, so they hold for the same content under any file name.
+type TriageEntry struct {
+	FileName    string `yaml:"file"`
+	Coordinates string `yaml:"coordinates"`
+}
+
+var (
+	triageOnce    sync.Once
+	triageEntries map[string]TriageEntry
+)
+
+// triageFile returns --triage, or else userlib.triage.yaml in the project
+// directory.
+func triageFile() string {
+	if path := viper.GetString("triage"); path != "" {
+		return path
+	}
+	targetDir := viper.GetString("target")
+	projectConfig := ""
+	if !viper.GetBool("ignore-project-config") {
+		projectConfig = findProjectConfig(targetDir)
+	}
+	return filepath.Join(projectDir(targetDir, projectConfig), triageFileName)
+}
+
+// loadTriage reads the coordinates assigned in triage, e.g.
+//
+//	3f8a...c2:
+//	  file: legacy-pdf.jar
+//	  coordinates: com.acme:legacy-pdf:1.4
+//
+// A missing file has no entries.
+func loadTriage(path string) map[string]TriageEntry {
+	entries := make(map[string]TriageEntry)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries
+	}
+	if err != nil {
+		fatalf("Unable to read triage file: %v", err)
+	}
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		fatalf("Unable to parse triage file %v: %v", path, err)
+	}
+	for checksum, entry := range entries {
+		if _, ok := parseTriageCoordinates(entry.Coordinates); !ok {
+			fatalf("Invalid coordinates %q for %v in %v, use group:artifact:version", entry.Coordinates, entry.FileName, path)
+		}
+		if lower := strings.ToLower(checksum); lower != checksum {
+			delete(entries, checksum)
+			entries[lower] = entry
+		}
+	}
+	return entries
+}
+
+func writeTriage(path string, entries map[string]TriageEntry) {
+	b, err := yaml.Marshal(entries)
+	if err != nil {
+		fatal(err)
+	}
+	content := "# Coordinates of JARs without metadata by SHA-256, assigned with triage.\n" + string(b)
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		fatalf("Unable to write triage file: %v", err)
+	}
+}
+
+// parseTriageCoordinates parses group:artifact:version.
+func parseTriageCoordinates(value string) (Coordinates, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 {
+		return Coordinates{}, false
+	}
+	for _, part := range parts {
+		if part == "" || strings.ContainsAny(part, " \t") {
+			return Coordinates{}, false
+		}
+	}
+	return Coordinates{group: parts[0], artifact: parts[1], version: parts[2]}, true
+}
+
+// identifyByTriage identifies a JAR by the coordinates assigned to its
+// content in triage.
+func identifyByTriage(filePath string, logger jarLog) JarProperties {
+	jarProp := JarProperties{filePath: filePath, fileName: filepath.Base(filePath)}
+	triageOnce.Do(func() {
+		triageEntries = loadTriage(triageFile())
+	})
+	if len(triageEntries) == 0 {
+		return jarProp
+	}
+	checksum, err := fileSHA256(filePath)
+	if err != nil {
+		logger.Warningf("Unable to hash %v: %v", filePath, err)
+		return jarProp
+	}
+	if entry, ok := triageEntries[checksum]; ok {
+		jarProp.Coordinates, _ = parseTriageCoordinates(entry.Coordinates)
+	}
+	return jarProp
+}
+
+// sampleEntries returns the first files of an archive, to recognize it by.
+func sampleEntries(archive *zip.Reader) []string {
+	entries := []string{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if len(entries) == triageSampleEntries {
+			entries = append(entries, fmt.Sprintf("... %d more", len(archive.File)-triageSampleEntries))
+			break
+		}
+		entries = append(entries, f.Name)
+	}
+	return entries
+}
+
+// triage lists the JARs in targetDir the parsers cannot identify with their
+// SHA-256, size and some of their entries, and asks for their coordinates.
+// Every answer is saved to the triage file right away, so later runs identify
+// the JAR by it. An empty answer skips a JAR.
+func triage(targetDir string, mode string) {
+	path := triageFile()
+	entries := loadTriage(path)
+
+	unidentified := []JarProperties{}
+	for _, jar := range listAllJars(listAllFiles(targetDir), mode) {
+		if isUnidentified(jar) && !jar.resourceOnly {
+			unidentified = append(unidentified, jar)
+		}
+	}
+	sort.Slice(unidentified, func(i, j int) bool { return unidentified[i].filePath < unidentified[j].filePath })
+	if len(unidentified) == 0 {
+		log.Info("All JARs are identified, nothing to triage")
+		return
+	}
+	log.Infof("%d JAR(s) to triage, assignments are saved to %v", len(unidentified), path)
+
+	assigned := 0
+	for i, jar := range unidentified {
+		checksum, err := fileSHA256(jar.filePath)
+		if err != nil {
+			fatalf("Unable to hash %v: %v", jar.fileName, err)
+		}
+		fmt.Fprintf(stderr, "\n[%d/%d] %v\n", i+1, len(unidentified), jar.filePath)
+		fmt.Fprintf(stderr, "  SHA-256: %v\n  Size:    %v\n  Entries:\n", checksum, formatBytes(jar.size))
+		if archive, err := openJar(jar.filePath); err == nil {
+			for _, name := range sampleEntries(archive.Reader) {
+				fmt.Fprintf(stderr, "    %v\n", name)
+			}
+			archive.Close()
+		}
+
+		for {
+			fmt.Fprintf(stderr, "Coordinates (group:artifact:version), empty to skip: ")
+			answer, err := stdin.ReadString('\n')
+			answer = strings.TrimSpace(answer)
+			if err != nil && answer == "" {
+				fmt.Fprintln(stderr)
+				log.Infof("Assigned coordinates to %d JAR(s)", assigned)
+				return
+			}
+			if answer == "" {
+				break
+			}
+			if _, ok := parseTriageCoordinates(answer); !ok {
+				fmt.Fprintf(stderr, "%q is not group:artifact:version\n", answer)
+				continue
+			}
+			entries[checksum] = TriageEntry{FileName: jar.fileName, Coordinates: answer}
+			writeTriage(path, entries)
+			assigned++
+			break
+		}
+	}
+	log.Infof("Assigned coordinates to %d JAR(s)", assigned)
+}
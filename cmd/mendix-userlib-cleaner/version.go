@@ -0,0 +1,402 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Versions are ordered like Maven's ComparableVersion: dots and dashes
+// separate the components, as do transitions between digits and letters.
+// Numbers of any length compare numerically, trailing zeros and release
+// qualifiers are insignificant (1.0 = 1 = 1.0.0-final), and qualifiers sort
+// alpha < beta < milestone < rc < snapshot < release < sp < anything else.
+// A dash starts a nested list, so 1-1 < 1.1.
+//
+// Snapshots are compared by their base version first and are older than the
+// release of the same base. Timestamped snapshots as deployed to a Maven
+// repository, like 2.3.0-20240110.123456-7, are ordered by timestamp and
+// build number, and 2.3.0-SNAPSHOT is the newest snapshot of its base.
+
+// qualifiers in ascending order, the empty string is a release
+var qualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var qualifierAliases = map[string]string{"ga": "", "final": "", "release": "", "cr": "rc"}
+
+type versionItem interface {
+	// compareTo compares with another item, or with nothing if other is nil
+	compareTo(other versionItem) int
+	isNull() bool
+	String() string
+}
+
+type numberItem string
+
+type qualifierItem string
+
+type listItem []versionItem
+
+func newNumberItem(digits string) numberItem {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return numberItem(digits)
+}
+
+func (n numberItem) isNull() bool { return n == "0" }
+
+func (n numberItem) String() string { return string(n) }
+
+func (n numberItem) compareTo(other versionItem) int {
+	switch other := other.(type) {
+	case nil:
+		if n.isNull() {
+			return 0
+		}
+		return 1
+	case numberItem:
+		if len(n) != len(other) {
+			return sign(len(n) - len(other))
+		}
+		return strings.Compare(string(n), string(other))
+	default:
+		// 1.1 > 1-1 and 1.1 > 1.alpha
+		return 1
+	}
+}
+
+// newQualifierItem normalizes a qualifier. Single letters followed by a
+// number abbreviate alpha, beta and milestone, e.g. 1.0a1.
+func newQualifierItem(value string, followedByDigit bool) qualifierItem {
+	if followedByDigit && len(value) == 1 {
+		switch value {
+		case "a":
+			value = "alpha"
+		case "b":
+			value = "beta"
+		case "m":
+			value = "milestone"
+		}
+	}
+	if alias, ok := qualifierAliases[value]; ok {
+		value = alias
+	}
+	return qualifierItem(value)
+}
+
+// comparableQualifier makes known qualifiers sort by their position and
+// unknown ones after all of them, alphabetically.
+func comparableQualifier(value string) string {
+	for i, qualifier := range qualifiers {
+		if qualifier == value {
+			return fmt.Sprint(i)
+		}
+	}
+	return fmt.Sprintf("%d-%v", len(qualifiers), value)
+}
+
+func (q qualifierItem) isNull() bool { return q == "" }
+
+func (q qualifierItem) String() string {
+	if q == "" {
+		return "release"
+	}
+	return string(q)
+}
+
+func (q qualifierItem) compareTo(other versionItem) int {
+	switch other := other.(type) {
+	case nil:
+		return sign(strings.Compare(comparableQualifier(string(q)), comparableQualifier("")))
+	case qualifierItem:
+		return sign(strings.Compare(comparableQualifier(string(q)), comparableQualifier(string(other))))
+	default:
+		return -1
+	}
+}
+
+func (l listItem) isNull() bool { return len(l) == 0 }
+
+func (l listItem) String() string {
+	parts := []string{}
+	for _, item := range l {
+		parts = append(parts, item.String())
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func (l listItem) compareTo(other versionItem) int {
+	switch other := other.(type) {
+	case nil:
+		for _, item := range l {
+			if result := item.compareTo(nil); result != 0 {
+				return result
+			}
+		}
+		return 0
+	case numberItem:
+		return -1
+	case qualifierItem:
+		return 1
+	case listItem:
+		for i := 0; i < len(l) || i < len(other); i++ {
+			if result := compareItems(itemAt(l, i), itemAt(other, i)); result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+func itemAt(l listItem, i int) versionItem {
+	if i < len(l) {
+		return l[i]
+	}
+	return nil
+}
+
+func compareItems(a versionItem, b versionItem) int {
+	if a == nil {
+		if b == nil {
+			return 0
+		}
+		return -b.compareTo(nil)
+	}
+	return a.compareTo(b)
+}
+
+// normalize drops trailing null items, like the zeros of 1.0.0.
+func (l listItem) normalize() listItem {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].isNull() {
+			l = append(l[:i], l[i+1:]...)
+		} else if _, ok := l[i].(listItem); !ok {
+			break
+		}
+	}
+	return l
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// parseVersion splits a version into its comparable items.
+func parseVersion(version string) listItem {
+	version = strings.ToLower(version)
+	// lists are built innermost last, the stack holds the open ones
+	stack := []listItem{{}}
+	add := func(item versionItem) {
+		stack[len(stack)-1] = append(stack[len(stack)-1], item)
+	}
+	open := func() {
+		stack = append(stack, listItem{})
+	}
+	token := func(text string, digits bool, followedByDigit bool) versionItem {
+		if digits {
+			return newNumberItem(text)
+		}
+		return newQualifierItem(text, followedByDigit)
+	}
+
+	digits := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				add(newNumberItem("0"))
+			} else {
+				add(token(version[start:i], digits, false))
+			}
+			start = i + 1
+			if c == '-' {
+				open()
+			}
+		case isDigit(c):
+			if !digits && i > start {
+				add(token(version[start:i], false, true))
+				start = i
+				open()
+			}
+			digits = true
+		default:
+			if digits && i > start {
+				add(token(version[start:i], true, false))
+				start = i
+				open()
+			}
+			digits = false
+		}
+	}
+	if len(version) > start {
+		add(token(version[start:], digits, false))
+	}
+
+	// close the nested lists, each is the last item of its parent
+	for len(stack) > 1 {
+		inner := stack[len(stack)-1].normalize()
+		stack = stack[:len(stack)-1]
+		stack[len(stack)-1] = append(stack[len(stack)-1], inner)
+	}
+	return stack[0].normalize()
+}
+
+var timestampedSnapshot = regexp.MustCompile(`^(.+)-([0-9]{8}\.[0-9]{6})-([0-9]+)$`)
+
+// snapshot is the parsed form of a snapshot version.
+type snapshot struct {
+	base string
+	// timestamp and build are empty for an unresolved -SNAPSHOT
+	timestamp string
+	build     string
+}
+
+// parseSnapshot splits a snapshot version into base version, timestamp and
+// build number. ok is false for other versions.
+func parseSnapshot(version string) (snapshot, bool) {
+	if match := timestampedSnapshot.FindStringSubmatch(version); match != nil {
+		return snapshot{base: match[1], timestamp: match[2], build: match[3]}, true
+	}
+	if len(version) > len("-snapshot") && strings.EqualFold(version[len(version)-len("-snapshot"):], "-snapshot") {
+		return snapshot{base: version[:len(version)-len("-snapshot")]}, true
+	}
+	return snapshot{}, false
+}
+
+func isSnapshot(version string) bool {
+	_, ok := parseSnapshot(version)
+	return ok
+}
+
+// compareSnapshots orders versions of which at least one is a snapshot, and
+// explains the order.
+func compareSnapshots(a string, b string) (int, string, bool) {
+	snapshotA, okA := parseSnapshot(a)
+	snapshotB, okB := parseSnapshot(b)
+	if !okA && !okB {
+		return 0, "", false
+	}
+	baseA, baseB := a, b
+	if okA {
+		baseA = snapshotA.base
+	}
+	if okB {
+		baseB = snapshotB.base
+	}
+	if result := parseVersion(baseA).compareTo(parseVersion(baseB)); result != 0 {
+		itemA, itemB := decidingItems(parseVersion(baseA), parseVersion(baseB))
+		return result, fmt.Sprintf("%v %v %v", describeItem(itemA, itemB), map[int]string{-1: "<", 1: ">"}[result], describeItem(itemB, itemA)), true
+	}
+	switch {
+	case !okA:
+		return 1, "release > snapshot", true
+	case !okB:
+		return -1, "snapshot < release", true
+	case snapshotA.timestamp == "" && snapshotB.timestamp == "":
+		return 0, "", true
+	case snapshotA.timestamp == "":
+		return 1, "SNAPSHOT > " + snapshotB.timestamp, true
+	case snapshotB.timestamp == "":
+		return -1, snapshotA.timestamp + " < SNAPSHOT", true
+	case snapshotA.timestamp != snapshotB.timestamp:
+		result := sign(strings.Compare(snapshotA.timestamp, snapshotB.timestamp))
+		return result, fmt.Sprintf("%v %v %v", snapshotA.timestamp, map[int]string{-1: "<", 1: ">"}[result], snapshotB.timestamp), true
+	}
+	result := newNumberItem(snapshotA.build).compareTo(newNumberItem(snapshotB.build))
+	return result, fmt.Sprintf("build %v %v %v", snapshotA.build, map[int]string{-1: "<", 0: "=", 1: ">"}[result], snapshotB.build), true
+}
+
+// isPreRelease tells whether a version has an alpha, beta, milestone, rc or
+// snapshot qualifier.
+func isPreRelease(version string) bool {
+	var walk func(list listItem) bool
+	walk = func(list listItem) bool {
+		for _, item := range list {
+			switch item := item.(type) {
+			case qualifierItem:
+				if item.compareTo(nil) < 0 {
+					return true
+				}
+			case listItem:
+				if walk(item) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return isSnapshot(version) || walk(parseVersion(version))
+}
+
+// compareVersions returns -1, 0 or 1 when version a is older than, equal to
+// or newer than version b.
+func compareVersions(a string, b string) int {
+	if result, _, ok := compareSnapshots(a, b); ok {
+		return result
+	}
+	return parseVersion(a).compareTo(parseVersion(b))
+}
+
+// versionOrder explains how two versions compare by the first items that
+// differ, e.g. "1.10.0 > 1.9.2: 10 > 9", for logs and reports.
+func versionOrder(a string, b string) string {
+	operators := map[int]string{-1: "<", 0: "=", 1: ">"}
+	result := compareVersions(a, b)
+	if result == 0 {
+		return fmt.Sprintf("%v = %v", a, b)
+	}
+	if _, rationale, ok := compareSnapshots(a, b); ok {
+		return fmt.Sprintf("%v %v %v: %v", a, operators[result], b, rationale)
+	}
+	itemA, itemB := decidingItems(parseVersion(a), parseVersion(b))
+	return fmt.Sprintf("%v %v %v: %v %v %v", a, operators[result], b, describeItem(itemA, itemB), operators[result], describeItem(itemB, itemA))
+}
+
+// decidingItems returns the first pair of items that differ, descending
+// into nested lists that differ.
+func decidingItems(a listItem, b listItem) (versionItem, versionItem) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		itemA, itemB := itemAt(a, i), itemAt(b, i)
+		if compareItems(itemA, itemB) == 0 {
+			continue
+		}
+		listA, okA := itemA.(listItem)
+		listB, okB := itemB.(listItem)
+		if okA && okB {
+			return decidingItems(listA, listB)
+		}
+		return itemA, itemB
+	}
+	return nil, nil
+}
+
+// describeItem names an item, or what a missing item stands for next to
+// the other one.
+func describeItem(item versionItem, other versionItem) string {
+	if list, ok := item.(listItem); ok {
+		// a nested list follows a dash
+		if len(list) == 0 {
+			return "release"
+		}
+		return "-" + describeItem(list[0], nil)
+	}
+	if item != nil {
+		return item.String()
+	}
+	if _, ok := other.(numberItem); ok {
+		return "0"
+	}
+	return "release"
+}
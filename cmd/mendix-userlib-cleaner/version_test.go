@@ -0,0 +1,92 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		// padding and leading zeros
+		{"1.0", "1.0.0", 0},
+		{"1", "1.0", 0},
+		{"1.0.0.0", "1", 0},
+		{"1.01", "1.1", 0},
+		{"1.0.1", "1.0", 1},
+		{"1.10", "1.9", 1},
+		{"1.2.10", "1.2.9", 1},
+		{"2.0", "10.0", -1},
+		{"1.0.20240101", "1.0.9", 1},
+
+		// qualifier order
+		{"1.0-alpha-1", "1.0-beta-1", -1},
+		{"1.0-beta-2", "1.0-milestone-1", -1},
+		{"1.0-milestone-1", "1.0-rc-1", -1},
+		{"1.0-rc-1", "1.0-SNAPSHOT", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"1.0", "1.0-sp1", -1},
+		{"1.0-sp1", "1.0-foo", -1},
+		{"1.0-alpha-2", "1.0-alpha-10", -1},
+		{"1.0-final", "1.0", 0},
+		{"1.0-ga", "1.0.0", 0},
+		{"1.0.RELEASE", "1.0", 0},
+		{"1.0-cr1", "1.0-rc1", 0},
+		{"1.0-RC1", "1.0-rc1", 0},
+		{"2.0.0.M1", "2.0.0.RC1", -1},
+		{"2.0.0.RC1", "2.0.0.RELEASE", -1},
+
+		// mixed alphanumeric tokens
+		{"1.0a1", "1.0-alpha-1", 0},
+		{"1.0b2", "1.0-beta-2", 0},
+		{"1.0m3", "1.0-milestone-3", 0},
+		{"1.0rc1", "1.0-rc-1", 0},
+		{"1.0b2", "1.0", -1},
+		{"9.4.50.v20221201", "9.4.51.v20230217", -1},
+		{"1.0.0.v20230101", "1.0.0.v20230102", -1},
+		{"1-1", "1.1", -1},
+		{"1.1", "1.alpha", 1},
+
+		// snapshots
+		{"2.3.0-SNAPSHOT", "2.3.0", -1},
+		{"2.3.0-snapshot", "2.3.0-SNAPSHOT", 0},
+		{"2.3.0-SNAPSHOT", "2.3.1-SNAPSHOT", -1},
+		{"2.2.9", "2.3.0-SNAPSHOT", -1},
+		{"2.3.0-20240110.123456-7", "2.3.0-20240110.123456-8", -1},
+		{"2.3.0-20240110.123456-9", "2.3.0-20240110.123456-10", -1},
+		{"2.3.0-20240110.123456-8", "2.3.0-20240111.000000-1", -1},
+		{"2.3.0-20240111.000000-1", "2.3.0-SNAPSHOT", -1},
+		{"2.3.0-20240111.000000-1", "2.3.0", -1},
+		{"2.2.9", "2.3.0-20240110.123456-7", -1},
+		{"2.3.0-20240110.123456-7", "2.3.0-20240110.123456-7", 0},
+	}
+	for _, test := range tests {
+		if got := compareVersions(test.a, test.b); got != test.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := compareVersions(test.b, test.a); got != -test.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func TestIsPreRelease(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.0", false},
+		{"1.0-final", false},
+		{"1.0-sp1", false},
+		{"1.0-alpha-1", true},
+		{"1.0b2", true},
+		{"2.0.0.M1", true},
+		{"2.0.0.RC1", true},
+		{"2.3.0-SNAPSHOT", true},
+		{"2.3.0-20240110.123456-7", true},
+	}
+	for _, test := range tests {
+		if got := isPreRelease(test.version); got != test.want {
+			t.Errorf("isPreRelease(%q) = %v, want %v", test.version, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type versionConstraint struct {
+	operator string
+	version  string
+}
+
+type versionRange struct {
+	text        string
+	constraints []versionConstraint
+}
+
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// parseVersionRange accepts space separated constraints like ">=4.5.13 <5".
+// A bare version means an exact match, an empty range or "*" matches anything.
+func parseVersionRange(text string) (versionRange, error) {
+	vr := versionRange{text: strings.TrimSpace(text)}
+	if vr.text == "" || vr.text == "*" {
+		return vr, nil
+	}
+	for _, token := range strings.Fields(vr.text) {
+		constraint := versionConstraint{operator: "=", version: token}
+		for _, operator := range versionOperators {
+			if strings.HasPrefix(token, operator) {
+				constraint.operator = operator
+				constraint.version = strings.TrimPrefix(token, operator)
+				break
+			}
+		}
+		if constraint.version == "" {
+			return vr, fmt.Errorf("missing version after %q in range %q", constraint.operator, text)
+		}
+		vr.constraints = append(vr.constraints, constraint)
+	}
+	return vr, nil
+}
+
+func (vr versionRange) contains(version string) bool {
+	for _, constraint := range vr.constraints {
+		diff := compareVersions(version, constraint.version)
+		ok := false
+		switch constraint.operator {
+		case ">=":
+			ok = diff >= 0
+		case "<=":
+			ok = diff <= 0
+		case ">":
+			ok = diff > 0
+		case "<":
+			ok = diff < 0
+		case "!=":
+			ok = diff != 0
+		default:
+			ok = diff == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (vr versionRange) String() string {
+	if vr.text == "" {
+		return "*"
+	}
+	return vr.text
+}
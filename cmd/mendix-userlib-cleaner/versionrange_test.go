@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		text        string
+		constraints []versionConstraint
+		err         bool
+	}{
+		{"", nil, false},
+		{"*", nil, false},
+		{"  ", nil, false},
+		{"4.5.13", []versionConstraint{{"=", "4.5.13"}}, false},
+		{"=4.5.13", []versionConstraint{{"=", "4.5.13"}}, false},
+		{">=4.5.13 <5", []versionConstraint{{">=", "4.5.13"}, {"<", "5"}}, false},
+		{"> 1", nil, true},
+		{">1 <=2 !=1.5", []versionConstraint{{">", "1"}, {"<=", "2"}, {"!=", "1.5"}}, false},
+		{">=", nil, true},
+		{"<1 !=", nil, true},
+	}
+	for _, test := range tests {
+		vr, err := parseVersionRange(test.text)
+		if (err != nil) != test.err {
+			t.Errorf("parseVersionRange(%q) error = %v, want error %v", test.text, err, test.err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(vr.constraints, test.constraints) {
+			t.Errorf("parseVersionRange(%q) = %v, want %v", test.text, vr.constraints, test.constraints)
+		}
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	tests := []struct {
+		text     string
+		version  string
+		contains bool
+	}{
+		{"*", "1.0", true},
+		{"", "anything", true},
+		{"1.0", "1.0", true},
+		{"1.0", "1.0.0", true},
+		{"1.0", "1", true},
+		{"=1.0", "1.0-final", true},
+		{"1.0", "1.0.1", false},
+		{"!=1.0", "1.0.0", false},
+		{"!=1.0", "1.0.1", true},
+		{">=4.5.13 <5", "4.5.13", true},
+		{">=4.5.13 <5", "4.5.9", false},
+		{">=4.5.13 <5", "4.10", true},
+		{">=4.5.13 <5", "5.0", false},
+		{">=4.5.13 <5", "5.0-rc1", true},
+		{">2.0", "2.0", false},
+		{">2.0", "2.0.1", true},
+		{"<=2.0", "2", true},
+		{"<2.0", "2.0-SNAPSHOT", true},
+	}
+	for _, test := range tests {
+		vr, err := parseVersionRange(test.text)
+		if err != nil {
+			t.Fatalf("parseVersionRange(%q): %v", test.text, err)
+		}
+		if got := vr.contains(test.version); got != test.contains {
+			t.Errorf("%q contains %v = %v, want %v", test.text, test.version, got, test.contains)
+		}
+	}
+}
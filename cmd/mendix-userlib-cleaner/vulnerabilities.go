@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+type vulnerability struct {
+	ID       string `yaml:"id"`
+	Versions string `yaml:"versions"`
+	affected versionRange
+}
+
+// loadVulnerabilities reads a YAML file mapping package names to known
+// vulnerabilities and the versions they affect, e.g.
+//
+//	org.apache.commons.text:
+//	  - id: CVE-2022-42889
+//	    versions: ">=1.5 <1.10.0"
+func loadVulnerabilities(path string) map[string][]vulnerability {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("Unable to read vulnerabilities: %v", err)
+	}
+	parsed := make(map[string][]vulnerability)
+	if err := yaml.Unmarshal(b, &parsed); err != nil {
+		fatalf("Unable to parse vulnerabilities %v: %v", path, err)
+	}
+	vulnerabilities := make(map[string][]vulnerability)
+	count := 0
+	for packageName, entries := range parsed {
+		for i := range entries {
+			vr, err := parseVersionRange(entries[i].Versions)
+			if err != nil {
+				fatalf("Invalid vulnerability %v of %v: %v", entries[i].ID, packageName, err)
+			}
+			entries[i].affected = vr
+			count++
+		}
+		vulnerabilities[normalizeKey(packageName)] = append(vulnerabilities[normalizeKey(packageName)], entries...)
+	}
+	log.Infof("Loaded %d vulnerabilities from %v", count, path)
+	return vulnerabilities
+}
+
+// vulnerabilitiesOf returns the IDs of the vulnerabilities affecting a JAR.
+func vulnerabilitiesOf(jar JarProperties, vulnerabilities map[string][]vulnerability) []string {
+	ids := []string{}
+	for _, v := range vulnerabilities[jar.fullKey()] {
+		if jar.version != "" && v.affected.contains(jar.version) {
+			ids = append(ids, v.ID)
+		}
+	}
+	return ids
+}
+
+// checkVulnerabilities reports kept JARs with known vulnerabilities. When a
+// duplicate without them exists, e.g. an older patched release, it is
+// suggested instead, and with --prefer-non-vulnerable it is kept.
+func checkVulnerabilities(jars []JarProperties, keepJars map[string]JarProperties, vulnerabilities map[string][]vulnerability) {
+	prefer := viper.GetBool("prefer-non-vulnerable")
+	packageNames := []string{}
+	for packageName := range keepJars {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	for _, packageName := range packageNames {
+		keepJar := keepJars[packageName]
+		ids := vulnerabilitiesOf(keepJar, vulnerabilities)
+		if len(ids) == 0 {
+			continue
+		}
+
+		found := false
+		var best JarProperties
+		for _, jar := range jars {
+			if jar.key() != packageName || len(vulnerabilitiesOf(jar, vulnerabilities)) > 0 {
+				continue
+			}
+			if !found || compareVersions(best.version, jar.version) < 0 {
+				best = jar
+				found = true
+			}
+		}
+
+		message := fmt.Sprintf("Kept %v is affected by %v", keepJar.fileName, strings.Join(ids, ", "))
+		if !found {
+			log.Warningf("%v, no unaffected duplicate present", message)
+			addFinding(Finding{Type: "vulnerable", Severity: severityCritical, Package: packageName, Message: message,
+				Files:        []string{keepJar.filePath},
+				SuggestedFix: &SuggestedFix{Action: "update-module", Target: packageName, Detail: "install a version not affected by " + strings.Join(ids, ", ")}})
+		} else if prefer {
+			log.Warningf("%v, keeping unaffected %v instead", message, best.fileName)
+			addFinding(Finding{Type: "vulnerable", Severity: severityWarning, Package: packageName,
+				Message:      fmt.Sprintf("%v, kept unaffected %v instead", message, best.fileName),
+				Files:        []string{keepJar.filePath, best.filePath},
+				SuggestedFix: removeFileFix(keepJar.filePath)})
+			keepJars[packageName] = best
+		} else {
+			log.Warningf("%v, unaffected %v is present", message, best.fileName)
+			addFinding(Finding{Type: "vulnerable", Severity: severityCritical, Package: packageName,
+				Message:      fmt.Sprintf("%v, unaffected %v is present", message, best.fileName),
+				Files:        []string{keepJar.filePath, best.filePath},
+				SuggestedFix: pinFix(packageName, best.version, "keep the release not affected by "+strings.Join(ids, ", "))})
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/op/go-logging"
+	"github.com/spf13/viper"
+)
+
+// watchDebounce is how long a target has to be quiet before it is analyzed
+// again, so importing a module that adds many JARs is analyzed once.
+const watchDebounce = 2 * time.Second
+
+// watchedFindings are the findings of the last analysis of each target, by
+// findingKey.
+var watchedFindings = make(map[string]map[string]Finding)
+
+func findingKey(finding Finding) string {
+	return finding.Type + "|" + finding.Package + "|" + strings.Join(finding.Files, "|")
+}
+
+// rememberFindings keeps the findings of the analysis of a target, to tell
+// what is new in the next.
+func rememberFindings(targetDir string) map[string]Finding {
+	previous := watchedFindings[targetDir]
+	current := make(map[string]Finding)
+	for _, finding := range reportFindings() {
+		current[findingKey(finding)] = finding
+	}
+	watchedFindings[targetDir] = current
+	return previous
+}
+
+// checkWatch fails early for targets that cannot be watched.
+func checkWatch(targets []string) {
+	if viper.GetString("files") != "" {
+		fatal("--watch analyzes the whole target, it cannot be used with --files")
+	}
+	for _, targetDir := range targets {
+		if isExtractedPackage(targetDir) {
+			fatalf("--watch needs a directory as --target, %v is not", reportPath(targetDir))
+		}
+		if _, ok := remoteTargets[targetDir]; ok {
+			fatalf("--watch needs a directory as --target, %v is not", reportPath(targetDir))
+		}
+	}
+}
+
+// watch analyzes the targets again whenever JARs are added, changed or
+// removed, until interrupted, and prints only the findings that are new
+// since the previous analysis. The analysis never removes files.
+func watch(targets []string, labels []string, outputs map[string]string, projectConfig string, mode string, policy Policy, exporters []Exporter) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fatalf("Unable to watch: %v", err)
+	}
+	defer watcher.Close()
+	for _, targetDir := range targets {
+		for _, dir := range watchedDirs(targetDir) {
+			if err := watcher.Add(dir); err != nil {
+				fatalf("Unable to watch %v: %v", dir, err)
+			}
+		}
+	}
+	log.Infof("Watching %v for changes, press Ctrl+C to stop", strings.Join(targets, ", "))
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case event := <-watcher.Events:
+			targetDir := watchedTarget(targets, event.Name)
+			if targetDir == "" || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				if event.Op&fsnotify.Create != 0 && viper.GetBool("recursive") {
+					for _, dir := range watchedDirs(event.Name) {
+						watcher.Add(dir)
+					}
+				}
+				continue
+			}
+			if !isArchive(event.Name) && !strings.HasSuffix(event.Name, ".meta") && !strings.HasSuffix(event.Name, ".RequiredLib") {
+				continue
+			}
+			log.Debugf("%v: %v", event.Op, event.Name)
+			pending[targetDir] = true
+			timer.Reset(watchDebounce)
+		case err := <-watcher.Errors:
+			log.Warningf("Watching failed: %v", err)
+		case <-timer.C:
+			for i, targetDir := range targets {
+				if !pending[targetDir] {
+					continue
+				}
+				delete(pending, targetDir)
+				label := ""
+				config := projectConfig
+				if len(targets) > 1 {
+					label = labels[i]
+					useTarget(targetDir, label, outputs)
+					if !viper.GetBool("ignore-project-config") {
+						config = findProjectConfig(targetDir)
+					}
+				} else {
+					resetTarget()
+				}
+				reanalyze(targetDir, label, config, mode, policy, exporters)
+			}
+		}
+	}
+}
+
+// reanalyze analyzes a target without its usual output and prints what
+// changed since the previous analysis.
+func reanalyze(targetDir string, label string, projectConfig string, mode string, policy Policy, exporters []Exporter) {
+	level := logging.GetLevel("main")
+	logging.SetLevel(logging.ERROR, "main")
+	summary, _, _ := runTarget(targetDir, projectConfig, mode, false, policy, exporters)
+	logging.SetLevel(level, "main")
+
+	prefix := ""
+	if label != "" {
+		prefix = label + ": "
+	}
+	previous := rememberFindings(targetDir)
+	current := watchedFindings[targetDir]
+	added := []Finding{}
+	for key, finding := range current {
+		if _, ok := previous[key]; !ok {
+			added = append(added, finding)
+		}
+	}
+	resolved := 0
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			resolved++
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return findingKey(added[i]) < findingKey(added[j]) })
+	for _, finding := range added {
+		switch finding.Severity {
+		case severityCritical:
+			log.Errorf("%vNew: %v", prefix, finding.Message)
+		case severityWarning:
+			log.Warningf("%vNew: %v", prefix, finding.Message)
+		default:
+			log.Infof("%vNew: %v", prefix, finding.Message)
+		}
+	}
+	log.Infof("%v%d new finding(s), %d resolved, %d duplicate JAR(s) to remove", prefix, len(added), resolved, summary.removals)
+}
+
+// watchedDirs returns dir, and its subdirectories with --recursive.
+func watchedDirs(dir string) []string {
+	if !viper.GetBool("recursive") {
+		return []string{dir}
+	}
+	dirs := []string{}
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// watchedTarget returns the target a changed file is in.
+func watchedTarget(targets []string, filePath string) string {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return ""
+	}
+	for _, targetDir := range targets {
+		if dir, err := filepath.Abs(targetDir); err == nil && isInside(dir, abs) {
+			return targetDir
+		}
+	}
+	return ""
+}